@@ -0,0 +1,144 @@
+// Package config loads and saves user configuration stored at
+// `~/.latai/config.json`. All fields are optional, missing file
+// is equivalent to an empty configuration.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/evaluator"
+	"github.com/pvlbzn/latai/internal/pricing"
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+	"github.com/pvlbzn/latai/internal/scheduler"
+)
+
+// DefaultFavorites is a name of favorites set which is restored on start.
+const DefaultFavorites = "default"
+
+// Config is a user configuration.
+type Config struct {
+	// Pricing overrides built-in model prices, keyed by provider
+	// name and model ID, in USD per one million tokens.
+	Pricing pricing.Table `json:"pricing,omitempty"`
+
+	// Favorites are named sets of marked models.
+	Favorites map[string][]ModelRef `json:"favorites,omitempty"`
+
+	// Scheduler limits concurrency and rate of requests, globally and
+	// per provider.
+	Scheduler *scheduler.Config `json:"scheduler,omitempty"`
+
+	// Run configures how each model is measured.
+	Run *Run `json:"run,omitempty"`
+}
+
+// Run configures measurement of a model. Zero values fall back to defaults.
+type Run struct {
+	// SampleSize is a number of samples of a model, one sample per
+	// prompt if not set.
+	SampleSize int `json:"sample_size,omitempty"`
+
+	// PromptMode defines how prompts are picked for samples.
+	PromptMode evaluator.PromptMode `json:"prompt_mode,omitempty"`
+
+	// TimeoutSeconds limits each call, calls are not limited if not set.
+	TimeoutSeconds int `json:"timeout_seconds,omitempty"`
+
+	// Warmup is a number of calls made before sampling which are not
+	// measured.
+	Warmup int `json:"warmup,omitempty"`
+
+	// Sampling narrows user-defined prompts down, e.g. a large dataset.
+	// All prompts are used if not set.
+	Sampling *prompt.Sampling `json:"sampling,omitempty"`
+}
+
+// Timeout returns limit of each call, zero if calls are not limited.
+func (r Run) Timeout() time.Duration {
+	return time.Duration(r.TimeoutSeconds) * time.Second
+}
+
+// PromptSampling returns sampling of user-defined prompts, all prompts are
+// kept if not set.
+func (r Run) PromptSampling() prompt.Sampling {
+	if r.Sampling == nil {
+		return prompt.Sampling{}
+	}
+	return *r.Sampling
+}
+
+// RunConfig returns run configuration, defaults if not set.
+func (c *Config) RunConfig() Run {
+	if c.Run == nil {
+		return Run{}
+	}
+	return *c.Run
+}
+
+// SchedulerConfig returns scheduler configuration, defaults if not set.
+func (c *Config) SchedulerConfig() scheduler.Config {
+	if c.Scheduler == nil {
+		return scheduler.Config{}
+	}
+	return *c.Scheduler
+}
+
+// ModelRef references a model by its provider and ID, which unlike table
+// row IDs are stable between sessions.
+type ModelRef struct {
+	Provider provider.ModelProvider `json:"provider"`
+	ID       string                 `json:"id"`
+}
+
+// Path returns location of the configuration file.
+func Path() string {
+	return filepath.Join(os.Getenv("HOME"), ".latai", "config.json")
+}
+
+// Load reads configuration from default location.
+func Load() (*Config, error) {
+	return LoadFrom(Path())
+}
+
+// LoadFrom reads configuration from a given path.
+func LoadFrom(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+
+	return &c, nil
+}
+
+// Save writes configuration to default location.
+func (c *Config) Save() error {
+	return c.SaveTo(Path())
+}
+
+// SaveTo writes configuration to a given path creating missing directories.
+func (c *Config) SaveTo(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/evaluator"
+	"github.com/pvlbzn/latai/internal/pricing"
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+)
+
+func TestLoadMissingFile(t *testing.T) {
+	c, err := LoadFrom(filepath.Join(t.TempDir(), "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c == nil || len(c.Pricing) != 0 {
+		t.Errorf("expected empty config, got %+v", c)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.json")
+
+	c := &Config{
+		Pricing: pricing.Table{
+			provider.ModelProviderOpenAI: {"gpt-4o": {Input: 1, Output: 2}},
+		},
+		Favorites: map[string][]ModelRef{
+			DefaultFavorites: {{Provider: provider.ModelProviderBedrock, ID: "amazon.nova-lite-v1:0"}},
+		},
+		Run: &Run{
+			SampleSize:     20,
+			PromptMode:     evaluator.PromptModeRandom,
+			TimeoutSeconds: 30,
+			Sampling:       &prompt.Sampling{Mode: prompt.SampleStratified, N: 50, Seed: 7},
+		},
+	}
+	if err := c.SaveTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadFrom(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p := loaded.Pricing[provider.ModelProviderOpenAI]["gpt-4o"]; p.Input != 1 || p.Output != 2 {
+		t.Errorf("unexpected price after reload: %+v", p)
+	}
+
+	favorites := loaded.Favorites[DefaultFavorites]
+	if len(favorites) != 1 || favorites[0] != c.Favorites[DefaultFavorites][0] {
+		t.Errorf("unexpected favorites after reload: %+v", favorites)
+	}
+
+	run := loaded.RunConfig()
+	if run.SampleSize != 20 || run.PromptMode != evaluator.PromptModeRandom || run.Timeout() != 30*time.Second {
+		t.Errorf("unexpected run configuration after reload: %+v", run)
+	}
+	if run.PromptSampling() != *c.Run.Sampling {
+		t.Errorf("unexpected sampling after reload: %+v", run.PromptSampling())
+	}
+}
+
+func TestSaveOmitsUnsetSampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := (&Config{Run: &Run{SampleSize: 5}}).SaveTo(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "sampling") {
+		t.Errorf("expected no sampling in saved configuration, got %s", data)
+	}
+}
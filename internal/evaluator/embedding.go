@@ -0,0 +1,299 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+	"github.com/pvlbzn/latai/internal/stats"
+)
+
+var (
+	// DefaultBatchSizes are numbers of inputs embedded by a single call.
+	DefaultBatchSizes = []int{1, 8, 32}
+
+	// DefaultInputWords are lengths of each input in words.
+	DefaultInputWords = []int{16, 256}
+)
+
+// DefaultEmbeddingSampleSize is a number of calls made for each batch size
+// and input length.
+const DefaultEmbeddingSampleSize = 3
+
+// EmbeddingEvaluator measures latency of an embedding model versus batch
+// size and input length. Every combination of them is a case measured by
+// its own samples.
+type EmbeddingEvaluator struct {
+	embedder   provider.Embedder
+	model      *provider.Model
+	batchSizes []int
+	inputWords []int
+	sampleSize int
+
+	// timeout limits each call, zero means no limit.
+	timeout time.Duration
+
+	retry RetryPolicy
+}
+
+// EmbeddingCase is a batch size and an input length measured together.
+type EmbeddingCase struct {
+	BatchSize  int
+	InputWords int
+}
+
+func (c EmbeddingCase) String() string {
+	return fmt.Sprintf("%d×%dw", c.BatchSize, c.InputWords)
+}
+
+// EmbeddingSample is a single call embedding a batch. A failed sample has
+// no metric and holds an error of its last attempt.
+type EmbeddingSample struct {
+	Metric *provider.EmbeddingMetric
+
+	// Err is nil if the sample succeeded.
+	Err error
+
+	// Attempts are all calls made for the sample. The last one is measured
+	// by Metric unless the sample failed.
+	Attempts []Attempt
+}
+
+// EmbeddingResult holds samples of a single case.
+type EmbeddingResult struct {
+	EmbeddingCase
+	Samples []*EmbeddingSample
+}
+
+// Latency returns latency of successful samples.
+func (r *EmbeddingResult) Latency() []time.Duration {
+	var latency []time.Duration
+	for _, s := range r.Samples {
+		if s.Err == nil {
+			latency = append(latency, s.Metric.Latency)
+		}
+	}
+
+	return latency
+}
+
+// Median returns median latency of successful samples, zero if all failed.
+func (r *EmbeddingResult) Median() time.Duration {
+	return stats.Median(r.Latency())
+}
+
+// ErrorRate returns a share of samples which failed, from 0 to 1.
+func (r *EmbeddingResult) ErrorRate() float64 {
+	if len(r.Samples) == 0 {
+		return 0
+	}
+
+	return 1 - float64(len(r.Latency()))/float64(len(r.Samples))
+}
+
+// InputTokens returns average token count of a batch reported by provider,
+// zero if provider didn't report it.
+func (r *EmbeddingResult) InputTokens() int {
+	total, n := 0, 0
+	for _, s := range r.Samples {
+		if s.Err == nil {
+			total += s.Metric.Response.InputTokens
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+
+	return total / n
+}
+
+// EmbeddingEvaluation is a result of an embedding evaluation, one result
+// per case in order of input lengths and then batch sizes.
+type EmbeddingEvaluation struct {
+	ModelName     string
+	ModelProvider string
+
+	// Dimensions is a length of returned vectors.
+	Dimensions int
+
+	Results []*EmbeddingResult
+}
+
+// Result returns result of a given case, nil if it wasn't measured.
+func (e *EmbeddingEvaluation) Result(c EmbeddingCase) *EmbeddingResult {
+	for _, r := range e.Results {
+		if r.EmbeddingCase == c {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// ErrorRate returns a share of samples of all cases which failed.
+func (e *EmbeddingEvaluation) ErrorRate() float64 {
+	failed, total := 0, 0
+	for _, r := range e.Results {
+		failed += len(r.Samples) - len(r.Latency())
+		total += len(r.Samples)
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return float64(failed) / float64(total)
+}
+
+func NewEmbeddingEvaluator(embedder provider.Embedder, model *provider.Model) *EmbeddingEvaluator {
+	return &EmbeddingEvaluator{
+		embedder:   embedder,
+		model:      model,
+		batchSizes: DefaultBatchSizes,
+		inputWords: DefaultInputWords,
+		sampleSize: DefaultEmbeddingSampleSize,
+		retry:      DefaultRetryPolicy,
+	}
+}
+
+// WithBatchSizes sets numbers of inputs embedded by a single call.
+func (e *EmbeddingEvaluator) WithBatchSizes(sizes ...int) *EmbeddingEvaluator {
+	e.batchSizes = sizes
+	return e
+}
+
+// WithInputWords sets lengths of each input in words.
+func (e *EmbeddingEvaluator) WithInputWords(words ...int) *EmbeddingEvaluator {
+	e.inputWords = words
+	return e
+}
+
+// WithSampleSize sets a number of calls made for each case.
+func (e *EmbeddingEvaluator) WithSampleSize(n int) *EmbeddingEvaluator {
+	e.sampleSize = n
+	return e
+}
+
+// WithTimeout limits duration of each call. A call which doesn't finish
+// in time fails with ErrTimeout.
+func (e *EmbeddingEvaluator) WithTimeout(d time.Duration) *EmbeddingEvaluator {
+	e.timeout = d
+	return e
+}
+
+// WithRetryPolicy sets how failed calls are retried, DefaultRetryPolicy
+// is used unless set.
+func (e *EmbeddingEvaluator) WithRetryPolicy(p RetryPolicy) *EmbeddingEvaluator {
+	e.retry = p
+	return e
+}
+
+// Cases returns cases measured by the evaluator in order.
+func (e *EmbeddingEvaluator) Cases() []EmbeddingCase {
+	var cases []EmbeddingCase
+	for _, words := range e.inputWords {
+		for _, size := range e.batchSizes {
+			cases = append(cases, EmbeddingCase{BatchSize: size, InputWords: words})
+		}
+	}
+
+	return cases
+}
+
+func (e *EmbeddingEvaluator) validate() error {
+	if e.embedder == nil {
+		return ErrNoProvider
+	}
+
+	if e.model == nil {
+		return ErrNoModel
+	}
+
+	if e.sampleSize <= 0 {
+		return ErrSampleSize
+	}
+
+	cases := e.Cases()
+	if len(cases) == 0 {
+		return ErrEmbeddingCase
+	}
+	for _, c := range cases {
+		if c.BatchSize <= 0 || c.InputWords <= 0 {
+			return ErrEmbeddingCase
+		}
+	}
+
+	return nil
+}
+
+// Evaluate measures each case with generated inputs, which never repeat
+// within an evaluation. A failed sample doesn't stop evaluation, evaluation
+// fails only if all samples of all cases failed. Cancelling context aborts
+// a running call and the rest of samples.
+func (e *EmbeddingEvaluator) Evaluate(ctx context.Context) (*EmbeddingEvaluation, error) {
+	if err := e.validate(); err != nil {
+		slog.Debug("failed to run embedding evaluator", "error", err.Error())
+		return nil, err
+	}
+
+	var results []*EmbeddingResult
+	var firstErr error
+	succeeded := false
+	offset := 0
+	dimensions := e.model.Dimensions
+
+	for _, c := range e.Cases() {
+		r := &EmbeddingResult{EmbeddingCase: c}
+		for i := 0; i < e.sampleSize; i++ {
+			inputs := prompt.EmbeddingInputs(c.BatchSize, c.InputWords, offset)
+			offset += c.BatchSize
+
+			m, attempts, err := withRetry(ctx, e.retry, e.call(inputs), func(m *provider.EmbeddingMetric) time.Duration { return m.Latency })
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			r.Samples = append(r.Samples, &EmbeddingSample{Metric: m, Err: err, Attempts: attempts})
+			if err == nil {
+				succeeded = true
+				if v := m.Response.Vectors; len(v) > 0 {
+					dimensions = len(v[0])
+				}
+			} else if firstErr == nil {
+				firstErr = err
+			}
+		}
+		results = append(results, r)
+	}
+
+	if !succeeded {
+		return nil, fmt.Errorf("%w: %w", ErrAllFailed, firstErr)
+	}
+
+	return &EmbeddingEvaluation{
+		ModelName:     e.model.Name,
+		ModelProvider: string(e.model.Provider),
+		Dimensions:    dimensions,
+		Results:       results,
+	}, nil
+}
+
+// call returns a call measuring a single batch within timeout, embedders
+// which pace requests are waited for before the timeout starts.
+func (e *EmbeddingEvaluator) call(inputs []string) func(context.Context) (*provider.EmbeddingMetric, error) {
+	return func(ctx context.Context) (*provider.EmbeddingMetric, error) {
+		if r, ok := e.embedder.(provider.EmbeddingReserver); ok {
+			var err error
+			if ctx, err = r.ReserveEmbedding(ctx, e.model, inputs); err != nil {
+				return nil, err
+			}
+		}
+
+		return withTimeout(ctx, e.timeout, func(ctx context.Context) (*provider.EmbeddingMetric, error) {
+			return e.embedder.MeasureEmbedding(ctx, e.model, inputs)
+		})
+	}
+}
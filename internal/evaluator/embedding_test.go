@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/provider"
+)
+
+// fakeEmbedder embeds each input into a vector of its word count. Batches
+// are recorded, errors, if any, are returned by the first calls.
+type fakeEmbedder struct {
+	batches [][]string
+	errs    []error
+}
+
+func (s *fakeEmbedder) Name() provider.ModelProvider { return "Fake" }
+
+func (s *fakeEmbedder) GetEmbeddingModels(filter string) []*provider.Model { return nil }
+
+func (s *fakeEmbedder) Embed(ctx context.Context, inputs []string, model *provider.Model) (*provider.EmbeddingResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.batches = append(s.batches, inputs)
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+
+	res := &provider.EmbeddingResponse{}
+	for _, in := range inputs {
+		n := len(strings.Fields(in))
+		res.Vectors = append(res.Vectors, []float32{float32(n), 0})
+		res.InputTokens += n
+	}
+
+	return res, nil
+}
+
+func (s *fakeEmbedder) MeasureEmbedding(ctx context.Context, model *provider.Model, inputs []string) (*provider.EmbeddingMetric, error) {
+	res, err := s.Embed(ctx, inputs, model)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.EmbeddingMetric{Model: model, Latency: time.Duration(len(inputs)) * time.Millisecond, Response: res}, nil
+}
+
+func TestEmbeddingEvaluate(t *testing.T) {
+	embedder := &fakeEmbedder{}
+	res, err := NewEmbeddingEvaluator(embedder, fakeModel).
+		WithBatchSizes(1, 4).
+		WithInputWords(8, 32).
+		WithSampleSize(2).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Results) != 4 || len(embedder.batches) != 8 {
+		t.Fatalf("expected 4 cases of 2 samples, got %d cases and %d calls", len(res.Results), len(embedder.batches))
+	}
+	if res.Dimensions != 2 {
+		t.Errorf("expected dimensions of returned vectors, got %d", res.Dimensions)
+	}
+
+	r := res.Result(EmbeddingCase{BatchSize: 4, InputWords: 32})
+	if r == nil {
+		t.Fatal("expected result of 4×32w case")
+	}
+	if r.Median() != 4*time.Millisecond || r.InputTokens() != 128 {
+		t.Errorf("unexpected result: median %s, %d tokens", r.Median(), r.InputTokens())
+	}
+
+	seen := map[string]bool{}
+	for _, b := range embedder.batches {
+		for _, in := range b {
+			if seen[in] {
+				t.Errorf("input %q repeats", in)
+			}
+			seen[in] = true
+		}
+	}
+}
+
+func TestEmbeddingEvaluateRetriesAndFailures(t *testing.T) {
+	embedder := &fakeEmbedder{errs: []error{provider.ErrAPIKeyInvalid, provider.ErrThrottled}}
+	res, err := NewEmbeddingEvaluator(embedder, fakeModel).
+		WithBatchSizes(1).
+		WithInputWords(8).
+		WithSampleSize(2).
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, Retryable: []error{provider.ErrThrottled}}).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := res.Results[0]
+	if !errors.Is(r.Samples[0].Err, provider.ErrAPIKeyInvalid) || len(r.Samples[0].Attempts) != 1 {
+		t.Errorf("expected the first sample to fail without retries, got %+v", r.Samples[0])
+	}
+	if r.Samples[1].Err != nil || len(r.Samples[1].Attempts) != 2 {
+		t.Errorf("expected the second sample to succeed on retry, got %+v", r.Samples[1])
+	}
+	if r.ErrorRate() != 0.5 {
+		t.Errorf("expected error rate 0.5, got %.2f", r.ErrorRate())
+	}
+}
+
+func TestEmbeddingEvaluateAllFailed(t *testing.T) {
+	embedder := &fakeEmbedder{errs: []error{provider.ErrAPIKeyInvalid}}
+	_, err := NewEmbeddingEvaluator(embedder, fakeModel).
+		WithBatchSizes(1).
+		WithInputWords(8).
+		WithSampleSize(1).
+		Evaluate(context.Background())
+	if !errors.Is(err, ErrAllFailed) || !errors.Is(err, provider.ErrAPIKeyInvalid) {
+		t.Errorf("expected ErrAllFailed wrapping cause, got %v", err)
+	}
+}
+
+func TestEmbeddingEvaluateInvalidCase(t *testing.T) {
+	_, err := NewEmbeddingEvaluator(&fakeEmbedder{}, fakeModel).
+		WithBatchSizes(0).
+		Evaluate(context.Background())
+	if !errors.Is(err, ErrEmbeddingCase) {
+		t.Errorf("expected ErrEmbeddingCase, got %v", err)
+	}
+}
@@ -1,9 +1,12 @@
 package evaluator
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"math/rand"
+	"strconv"
 	"time"
 
 	"github.com/pvlbzn/latai/internal/prompt"
@@ -15,6 +18,49 @@ var (
 	ErrNoModel    = errors.New("no model provided")
 	ErrNoPrompt   = errors.New("no prompt(s) provided")
 	ErrSampleSize = errors.New("sample size must be 1 or more")
+	ErrWarmup     = errors.New("warm-up count must not be negative")
+	ErrTimeout    = errors.New("call timed out")
+	ErrAllFailed  = errors.New("all samples failed")
+	ErrNoTools    = errors.New("no prompt declares tools")
+	ErrNoSchema   = errors.New("no prompt requests structured output")
+	ErrNoImages   = errors.New("no prompt attaches images")
+	ErrNoVision   = errors.New("model does not accept images")
+
+	ErrEmbeddingCase = errors.New("batch sizes and input lengths must be 1 or more")
+)
+
+// PromptMode defines how prompts are picked for samples.
+type PromptMode string
+
+const (
+	// PromptModeAuto runs each prompt once if sample size equals to number
+	// of prompts and picks random prompts otherwise.
+	PromptModeAuto PromptMode = ""
+
+	// PromptModeUnique runs prompts in order, starting over once all of
+	// them ran.
+	PromptModeUnique PromptMode = "unique"
+
+	// PromptModeRandom picks a random prompt for each sample.
+	PromptModeRandom PromptMode = "random"
+
+	// PromptModeCache runs prompts in order behind a long system prefix
+	// marked for caching. The first sample writes the prefix into cache
+	// and the rest may read it, see Evaluation.Cache.
+	PromptModeCache PromptMode = "cache"
+
+	// PromptModeTools runs only prompts which declare tools in order and
+	// checks that models answer them with valid tool calls.
+	PromptModeTools PromptMode = "tools"
+
+	// PromptModeStructured runs prompts which request structured output
+	// in pairs, once with response schema and once in free text, see
+	// Evaluation.Structured.
+	PromptModeStructured PromptMode = "structured"
+
+	// PromptModeVision runs prompts which attach images in pairs, once
+	// with images and once without, see Evaluation.Vision.
+	PromptModeVision PromptMode = "vision"
 )
 
 type Evaluator struct {
@@ -22,16 +68,383 @@ type Evaluator struct {
 	model      *provider.Model
 	prompts    []*prompt.Prompt
 	sampleSize int
-	// concurrency
-	// timeout
+	mode       PromptMode
+	progress   func(Progress)
+
+	// timeout limits each call, zero means no limit.
+	timeout time.Duration
+
+	// warmup is a number of calls made before sampling which are not
+	// measured, e.g. to let a provider load a model.
+	warmup int
+
+	retry RetryPolicy
 }
 
+// RetryPolicy defines how failed calls are retried. Only a successful
+// attempt of a sample is measured, failed attempts are recorded separately
+// so that retries never inflate latency.
+type RetryPolicy struct {
+	// MaxAttempts is a number of calls of a sample including the first
+	// one. One or less disables retries.
+	MaxAttempts int
+
+	// Backoff is a delay before the first retry, doubled before each next
+	// one up to MaxBackoff. Actual delay is random from zero up to it, so
+	// that models throttled together don't retry together.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Retryable are classes of provider errors worth retrying, such as
+	// provider.ErrThrottled. Calls exceeding timeout of the evaluator are
+	// never retried.
+	Retryable []error
+}
+
+// DefaultRetryPolicy retries transient failures: throttling, server
+// errors and timeouts reported by a provider.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	Backoff:     500 * time.Millisecond,
+	MaxBackoff:  8 * time.Second,
+	Retryable:   []error{provider.ErrThrottled, provider.ErrServer, provider.ErrTimeout},
+}
+
+// retryable reports whether a failed call should be retried.
+func (p RetryPolicy) retryable(err error) bool {
+	if errors.Is(err, ErrTimeout) {
+		return false
+	}
+
+	for _, class := range p.Retryable {
+		if errors.Is(err, class) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// backoff returns a random delay before a given retry, starting from one.
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 {
+		d = min(d, p.MaxBackoff)
+	}
+
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+// Progress of an evaluation, reported after each sample.
+type Progress struct {
+	Done   int
+	Total  int
+	Sample *Sample
+}
+
+// Evaluation is a result of an evaluation. Responses and latency stats
+// cover successful samples only, while Samples hold failed ones too.
 type Evaluation struct {
 	ModelName     string
 	ModelProvider string
+	Mode          PromptMode
 	Responses     []string
 	LatencyAvg    time.Duration
 	Latency       []time.Duration
+	Samples       []*Sample
+}
+
+// Sample is a single measurement along with the result of checking its
+// completion against prompt's expectation. A failed sample has no metric
+// and holds an error of its last attempt.
+type Sample struct {
+	Prompt *prompt.Prompt
+	Metric *provider.Metric
+
+	// Err is nil if the sample succeeded.
+	Err error
+
+	// Check is nil if completion met prompt's expectation.
+	Check error
+
+	// Attempts are all calls made for the sample. The last one is measured
+	// by Metric unless the sample failed.
+	Attempts []Attempt
+}
+
+// Attempt is a single call made for a sample.
+type Attempt struct {
+	Latency time.Duration
+
+	// Err is nil if the call succeeded.
+	Err error
+}
+
+// Succeeded reports whether a call of the sample succeeded.
+func (s *Sample) Succeeded() bool {
+	return s.Err == nil
+}
+
+// Passed reports whether sample succeeded and its completion met prompt's
+// expectation.
+func (s *Sample) Passed() bool {
+	return s.Succeeded() && s.Check == nil
+}
+
+// Retries returns a number of failed attempts of the sample.
+func (s *Sample) Retries() int {
+	return max(0, len(s.Attempts)-1)
+}
+
+// Retries returns a total number of failed attempts of all samples.
+func (e *Evaluation) Retries() int {
+	var n int
+	for _, s := range e.Samples {
+		n += s.Retries()
+	}
+
+	return n
+}
+
+// SuccessRate returns a share of samples which passed their checks,
+// from 0 to 1. Failed samples count as not passed.
+func (e *Evaluation) SuccessRate() float64 {
+	if len(e.Samples) == 0 {
+		return 0
+	}
+
+	passed := 0
+	for _, s := range e.Samples {
+		if s.Passed() {
+			passed++
+		}
+	}
+
+	return float64(passed) / float64(len(e.Samples))
+}
+
+// CacheResult compares latency of the first sample of cache mode, which
+// writes the shared prefix into cache, with later samples which may read it.
+type CacheResult struct {
+	// First is latency of the first successful sample.
+	First time.Duration
+
+	// Cached are latencies of successful samples after the first one.
+	Cached []time.Duration
+
+	// Hits is a number of samples after the first one which reported
+	// input tokens read from cache, CachedTokens is their total.
+	Hits         int
+	CachedTokens int
+}
+
+// Cache returns result of prompt caching measurement, nil if evaluation
+// didn't run in cache mode or less than two samples succeeded.
+func (e *Evaluation) Cache() *CacheResult {
+	if e.Mode != PromptModeCache {
+		return nil
+	}
+
+	var res *CacheResult
+	for _, s := range e.Samples {
+		if !s.Succeeded() {
+			continue
+		}
+
+		if res == nil {
+			res = &CacheResult{First: s.Metric.Latency}
+			continue
+		}
+
+		res.Cached = append(res.Cached, s.Metric.Latency)
+		if n := s.Metric.Response.CachedInputTokens; n > 0 {
+			res.Hits++
+			res.CachedTokens += n
+		}
+	}
+
+	if res == nil || len(res.Cached) == 0 {
+		return nil
+	}
+	return res
+}
+
+// StructuredResult compares samples of prompts which request structured
+// output with samples of prompts in free text.
+type StructuredResult struct {
+	// Structured and FreeText are latencies of successful samples.
+	Structured []time.Duration
+	FreeText   []time.Duration
+
+	// Valid is a number of successful structured samples which returned
+	// JSON valid against response schema. Extracted also counts samples
+	// which JSON is valid once extracted from code fences or prose.
+	Valid     int
+	Extracted int
+}
+
+// ValidityRate returns a share of successful structured samples which
+// returned valid JSON, from 0 to 1.
+func (r *StructuredResult) ValidityRate() float64 {
+	if len(r.Structured) == 0 {
+		return 0
+	}
+	return float64(r.Valid) / float64(len(r.Structured))
+}
+
+// ExtractedRate returns a share of successful structured samples which
+// JSON is valid once extracted, from 0 to 1.
+func (r *StructuredResult) ExtractedRate() float64 {
+	if len(r.Structured) == 0 {
+		return 0
+	}
+	return float64(r.Extracted) / float64(len(r.Structured))
+}
+
+// Structured returns result of structured output measurement, nil if no
+// successful sample requested structured output.
+func (e *Evaluation) Structured() *StructuredResult {
+	res := &StructuredResult{}
+	for _, s := range e.Samples {
+		if !s.Succeeded() {
+			continue
+		}
+
+		if len(s.Prompt.ResponseSchema) == 0 {
+			res.FreeText = append(res.FreeText, s.Metric.Latency)
+			continue
+		}
+
+		res.Structured = append(res.Structured, s.Metric.Latency)
+		if s.Prompt.CheckStructured(s.Metric.Response.Completion) == nil {
+			res.Valid++
+		}
+		if s.Prompt.CheckExtractedStructured(s.Metric.Response.Completion) == nil {
+			res.Extracted++
+		}
+	}
+
+	if len(res.Structured) == 0 {
+		return nil
+	}
+	return res
+}
+
+// VisionResult compares samples of prompts which attach images with
+// samples of prompts without them.
+type VisionResult struct {
+	// Image and Text are latencies of successful samples.
+	Image []time.Duration
+	Text  []time.Duration
+}
+
+// Vision returns result of vision measurement, nil if no successful sample
+// attached images.
+func (e *Evaluation) Vision() *VisionResult {
+	res := &VisionResult{}
+	for _, s := range e.Samples {
+		if !s.Succeeded() {
+			continue
+		}
+
+		if len(s.Prompt.Images) > 0 {
+			res.Image = append(res.Image, s.Metric.Latency)
+		} else {
+			res.Text = append(res.Text, s.Metric.Latency)
+		}
+	}
+
+	if len(res.Image) == 0 {
+		return nil
+	}
+	return res
+}
+
+// ReasoningResult splits output tokens of a sample into hidden reasoning
+// and visible output, averaged over successful samples.
+type ReasoningResult struct {
+	Reasoning int
+	Visible   int
+}
+
+// Reasoning returns split of output tokens, nil if no sample reported
+// reasoning tokens.
+func (e *Evaluation) Reasoning() *ReasoningResult {
+	var res ReasoningResult
+	n := 0
+	for _, s := range e.Samples {
+		if s.Succeeded() {
+			res.Reasoning += s.Metric.Response.ReasoningTokens
+			res.Visible += s.Metric.Response.VisibleOutputTokens()
+			n++
+		}
+	}
+	if res.Reasoning == 0 {
+		return nil
+	}
+
+	res.Reasoning /= n
+	res.Visible /= n
+	return &res
+}
+
+// ToolLatency returns latency of successful samples of prompts which
+// declare tools. Tool calling turns are measured apart from plain
+// completions, their latency differs.
+func (e *Evaluation) ToolLatency() []time.Duration {
+	return e.latency(func(s *Sample) bool { return len(s.Prompt.Tools) > 0 })
+}
+
+// TextLatency returns latency of successful samples of prompts which
+// don't declare tools.
+func (e *Evaluation) TextLatency() []time.Duration {
+	return e.latency(func(s *Sample) bool { return len(s.Prompt.Tools) == 0 })
+}
+
+// FirstToken returns time to first token of successful samples which
+// completion was streamed, empty if model or provider doesn't stream.
+func (e *Evaluation) FirstToken() []time.Duration {
+	var res []time.Duration
+	for _, s := range e.Samples {
+		if s.Succeeded() && s.Metric.FirstToken > 0 {
+			res = append(res, s.Metric.FirstToken)
+		}
+	}
+
+	return res
+}
+
+func (e *Evaluation) latency(match func(s *Sample) bool) []time.Duration {
+	var res []time.Duration
+	for _, s := range e.Samples {
+		if s.Succeeded() && match(s) {
+			res = append(res, s.Metric.Latency)
+		}
+	}
+
+	return res
+}
+
+// ErrorRate returns a share of samples which failed, from 0 to 1.
+func (e *Evaluation) ErrorRate() float64 {
+	if len(e.Samples) == 0 {
+		return 0
+	}
+
+	failed := 0
+	for _, s := range e.Samples {
+		if !s.Succeeded() {
+			failed++
+		}
+	}
+
+	return float64(failed) / float64(len(e.Samples))
 }
 
 func NewEvaluator(provider provider.Provider, model *provider.Model, prompts ...*prompt.Prompt) *Evaluator {
@@ -40,6 +453,7 @@ func NewEvaluator(provider provider.Provider, model *provider.Model, prompts ...
 		model:      model,
 		sampleSize: len(prompts),
 		prompts:    prompts,
+		retry:      DefaultRetryPolicy,
 	}
 }
 
@@ -48,6 +462,40 @@ func (e *Evaluator) WithSampleSize(n int) *Evaluator {
 	return e
 }
 
+// WithPromptMode sets how prompts are picked for samples.
+func (e *Evaluator) WithPromptMode(mode PromptMode) *Evaluator {
+	e.mode = mode
+	return e
+}
+
+// WithTimeout limits duration of each call. A call which doesn't finish
+// in time fails with ErrTimeout.
+func (e *Evaluator) WithTimeout(d time.Duration) *Evaluator {
+	e.timeout = d
+	return e
+}
+
+// WithWarmup sets a number of calls made before sampling. Their results
+// are discarded and progress is not reported for them.
+func (e *Evaluator) WithWarmup(n int) *Evaluator {
+	e.warmup = n
+	return e
+}
+
+// WithRetryPolicy sets how failed calls are retried, DefaultRetryPolicy
+// is used unless set.
+func (e *Evaluator) WithRetryPolicy(p RetryPolicy) *Evaluator {
+	e.retry = p
+	return e
+}
+
+// WithProgress sets a function called after each sample. It is called
+// from the goroutine running Evaluate and must not block for long.
+func (e *Evaluator) WithProgress(fn func(Progress)) *Evaluator {
+	e.progress = fn
+	return e
+}
+
 func (e *Evaluator) validate() error {
 	if e.provider == nil {
 		return ErrNoProvider
@@ -61,10 +509,32 @@ func (e *Evaluator) validate() error {
 		return ErrSampleSize
 	}
 
+	if e.warmup < 0 {
+		return ErrWarmup
+	}
+
 	if e.prompts == nil || len(e.prompts) == 0 {
 		return ErrNoPrompt
 	}
 
+	if e.mode == PromptModeTools && len(toolPrompts(e.prompts)) == 0 {
+		return ErrNoTools
+	}
+
+	if e.mode == PromptModeStructured && len(structuredPrompts(e.prompts)) == 0 {
+		return ErrNoSchema
+	}
+
+	if e.mode == PromptModeVision && len(visionPrompts(e.prompts)) == 0 {
+		return ErrNoImages
+	}
+
+	// Models which don't accept images can't run vision mode, other
+	// modes leave prompts with images out for them.
+	if !e.model.Vision && (e.mode == PromptModeVision || len(textPrompts(e.prompts)) == 0) {
+		return ErrNoVision
+	}
+
 	return nil
 }
 
@@ -81,8 +551,17 @@ func (e *Evaluator) validate() error {
 // If required, sample size may be changed using `Evaluator.WithSampleSize`. Evaluate
 // will detect that amount of prompts doesn't match sample size and will run sampling
 // picking up a random prompt from the prompt pool. This measurement might be affected
-// by prompt caching.
-func (e *Evaluator) Evaluate() (*Evaluation, error) {
+// by prompt caching. Prompt mode set by `Evaluator.WithPromptMode` overrides
+// this detection.
+//
+// Warm-up calls, if any, run before sampling and are not measured. Cache
+// mode skips them, so that its first sample always writes the cache.
+//
+// A failed sample doesn't stop evaluation, it is kept in the result along
+// with its error and is excluded from latency. Evaluation fails only if
+// all samples failed. Cancelling context aborts a running call and the
+// rest of samples.
+func (e *Evaluator) Evaluate(ctx context.Context) (*Evaluation, error) {
 	// Validate.
 	err := e.validate()
 	if err != nil {
@@ -90,70 +569,356 @@ func (e *Evaluator) Evaluate() (*Evaluation, error) {
 		return nil, err
 	}
 
-	// Get metrics.
-	var metrics []*provider.Metric
+	// Prompts with images are left out for models which don't accept them.
+	prompts := e.prompts
+	if !e.model.Vision && len(visionPrompts(prompts)) > 0 {
+		prompts = textPrompts(prompts)
+	}
 
-	if len(e.prompts) != e.sampleSize {
-		metrics, err = e.runRandomSample()
-	} else {
-		metrics, err = e.runUniqueSample()
+	mode := e.mode
+	if mode == PromptModeAuto {
+		mode = PromptModeRandom
+		if len(prompts) == e.sampleSize {
+			mode = PromptModeUnique
+		}
+	}
+
+	if mode != PromptModeCache {
+		if err := e.runWarmup(ctx, prompts); err != nil {
+			slog.Debug("failed to warm up", "error", err.Error())
+			return nil, err
+		}
+	}
+
+	// Get samples.
+	var samples []*Sample
+
+	switch mode {
+	case PromptModeRandom:
+		samples, err = e.runRandomSample(ctx, prompts)
+	case PromptModeCache:
+		samples, err = e.runCacheSample(ctx, prompts)
+	case PromptModeTools:
+		samples, err = e.runToolSample(ctx, prompts)
+	case PromptModeStructured:
+		samples, err = e.runPairedSample(ctx, structuredPrompts(prompts), (*prompt.Prompt).WithoutResponseSchema)
+	case PromptModeVision:
+		samples, err = e.runPairedSample(ctx, visionPrompts(prompts), (*prompt.Prompt).WithoutImages)
+	default:
+		samples, err = e.runUniqueSample(ctx, prompts)
 	}
 	if err != nil {
 		slog.Debug("failed to run a sample", "error", err.Error())
 		return nil, err
 	}
 
-	// Combine.
+	// Combine successful samples.
 	var responses []string
 	var latency []time.Duration
-	for _, m := range metrics {
-		latency = append(latency, m.Latency)
-		responses = append(responses, m.Response.Completion)
+	for _, s := range samples {
+		if s.Succeeded() {
+			latency = append(latency, s.Metric.Latency)
+			responses = append(responses, s.Metric.Response.Completion)
+		}
+	}
+
+	if len(latency) == 0 {
+		return nil, fmt.Errorf("%w: %w", ErrAllFailed, samples[0].Err)
 	}
 
 	var sum time.Duration
 	for _, m := range latency {
 		sum += m
 	}
-	avg := sum / time.Duration(len(metrics))
+	avg := sum / time.Duration(len(latency))
 
 	return &Evaluation{
 		ModelName:     e.model.Name,
 		ModelProvider: string(e.model.Provider),
+		Mode:          e.mode,
 		Responses:     responses,
 		LatencyAvg:    avg,
 		Latency:       latency,
+		Samples:       samples,
 	}, nil
 }
 
+// runWarmup makes warm-up calls going through prompts in order. Failed
+// calls are ignored, failures of a model show up in samples anyway.
+func (e *Evaluator) runWarmup(ctx context.Context, prompts []*prompt.Prompt) error {
+	for i := 0; i < e.warmup; i++ {
+		if _, _, err := e.callWithRetry(ctx, prompts[i%len(prompts)]); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			slog.Debug("warm-up call failed", "error", err.Error())
+		}
+	}
+
+	return nil
+}
+
 // runUniqueSample runs measurements which are unique and may defeat prompt caching.
-func (e *Evaluator) runUniqueSample() ([]*provider.Metric, error) {
-	var res []*provider.Metric
+// Prompts are re-used in order once sample size exceeds number of prompts.
+func (e *Evaluator) runUniqueSample(ctx context.Context, prompts []*prompt.Prompt) ([]*Sample, error) {
+	var res []*Sample
 
-	for _, p := range e.prompts {
-		m, err := e.provider.Measure(e.model, p)
+	for i := 0; i < e.sampleSize; i++ {
+		s, err := e.sample(ctx, prompts[i%len(prompts)])
 		if err != nil {
 			return nil, err
 		}
 
-		res = append(res, m)
+		res = append(res, s)
+		e.report(len(res), s)
 	}
 
 	return res, nil
 }
 
 // runRandomSample runs measurements picking up prompts randomly out of prompt pool.
-func (e *Evaluator) runRandomSample() ([]*provider.Metric, error) {
-	var res []*provider.Metric
+func (e *Evaluator) runRandomSample(ctx context.Context, prompts []*prompt.Prompt) ([]*Sample, error) {
+	var res []*Sample
+
+	for i := 0; i < e.sampleSize; i++ {
+		randomPrompt := prompts[rand.Intn(len(prompts))]
+		s, err := e.sample(ctx, randomPrompt)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+		e.report(len(res), s)
+	}
+
+	return res, nil
+}
+
+// runCacheSample runs prompts in order behind a shared prefix. The prefix
+// is unique to the run, so that its first sample is never served from
+// cache of a previous run. Models without system messages receive the
+// prefix in the first user turn.
+func (e *Evaluator) runCacheSample(ctx context.Context, prompts []*prompt.Prompt) ([]*Sample, error) {
+	prefix := prompt.CachePrefix(strconv.FormatInt(rand.Int63(), 36))
 
+	withPrefix := (*prompt.Prompt).WithCachedPrefix
+	if !e.model.SupportsSystemRole() {
+		withPrefix = (*prompt.Prompt).WithUserPrefix
+	}
+
+	var res []*Sample
 	for i := 0; i < e.sampleSize; i++ {
-		randomPrompt := e.prompts[rand.Intn(len(e.prompts))]
-		m, err := e.provider.Measure(e.model, randomPrompt)
+		s, err := e.sample(ctx, withPrefix(prompts[i%len(prompts)], prefix))
 		if err != nil {
 			return nil, err
 		}
-		res = append(res, m)
+
+		res = append(res, s)
+		e.report(len(res), s)
 	}
 
 	return res, nil
 }
+
+// runToolSample runs prompts which declare tools in order.
+func (e *Evaluator) runToolSample(ctx context.Context, prompts []*prompt.Prompt) ([]*Sample, error) {
+	prompts = toolPrompts(prompts)
+
+	var res []*Sample
+	for i := 0; i < e.sampleSize; i++ {
+		s, err := e.sample(ctx, prompts[i%len(prompts)])
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, s)
+		e.report(len(res), s)
+	}
+
+	return res, nil
+}
+
+func toolPrompts(prompts []*prompt.Prompt) []*prompt.Prompt {
+	var res []*prompt.Prompt
+	for _, p := range prompts {
+		if len(p.Tools) > 0 {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// runPairedSample runs prompts in order, each one twice: as is and as
+// a plain variant, e.g. without response schema. Which of the pair runs
+// first alternates, so that neither gains from running after the other.
+func (e *Evaluator) runPairedSample(ctx context.Context, prompts []*prompt.Prompt, plain func(*prompt.Prompt) *prompt.Prompt) ([]*Sample, error) {
+	var res []*Sample
+	for i := 0; i < e.sampleSize; i++ {
+		pair := i / 2
+		p := prompts[pair%len(prompts)]
+		if (i+pair)%2 == 1 {
+			p = plain(p)
+		}
+
+		s, err := e.sample(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, s)
+		e.report(len(res), s)
+	}
+
+	return res, nil
+}
+
+func structuredPrompts(prompts []*prompt.Prompt) []*prompt.Prompt {
+	var res []*prompt.Prompt
+	for _, p := range prompts {
+		if len(p.ResponseSchema) > 0 {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+func visionPrompts(prompts []*prompt.Prompt) []*prompt.Prompt {
+	var res []*prompt.Prompt
+	for _, p := range prompts {
+		if len(p.Images) > 0 {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// textPrompts returns prompts which don't attach images.
+func textPrompts(prompts []*prompt.Prompt) []*prompt.Prompt {
+	var res []*prompt.Prompt
+	for _, p := range prompts {
+		if len(p.Images) == 0 {
+			res = append(res, p)
+		}
+	}
+
+	return res
+}
+
+// report reports progress after a sample if progress function is set.
+func (e *Evaluator) report(done int, s *Sample) {
+	if e.progress != nil {
+		e.progress(Progress{Done: done, Total: e.sampleSize, Sample: s})
+	}
+}
+
+// sample measures a single prompt and checks its completion against
+// response schema and expectation, or its tool calls if prompt declares
+// tools. A failed call results in a failed sample,
+// error is returned only if context is cancelled.
+func (e *Evaluator) sample(ctx context.Context, p *prompt.Prompt) (*Sample, error) {
+	m, attempts, err := e.callWithRetry(ctx, p)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return &Sample{Prompt: p, Err: err, Attempts: attempts}, nil
+	}
+
+	check := p.CheckStructured(m.Response.Completion)
+	if check == nil {
+		check = p.Expect.Check(m.Response.Completion)
+	}
+	if len(p.Tools) > 0 {
+		check = p.CheckToolCalls(m.Response.ToolCalls)
+	}
+
+	return &Sample{
+		Prompt:   p,
+		Metric:   m,
+		Check:    check,
+		Attempts: attempts,
+	}, nil
+}
+
+// callWithRetry measures a single prompt retrying failures according to
+// retry policy. Returns metric of the successful attempt and all attempts.
+func (e *Evaluator) callWithRetry(ctx context.Context, p *prompt.Prompt) (*provider.Metric, []Attempt, error) {
+	return withRetry(ctx, e.retry, e.call(p), func(m *provider.Metric) time.Duration { return m.Latency })
+}
+
+// withRetry makes a call retrying failures according to retry policy.
+// Returns result of the successful attempt and all attempts, latency
+// of the successful one is measured by the call itself.
+func withRetry[T any](ctx context.Context, policy RetryPolicy, call func(context.Context) (T, error), latency func(T) time.Duration) (T, []Attempt, error) {
+	var zero T
+	var attempts []Attempt
+	for attempt := 1; ; attempt++ {
+		start := time.Now()
+		res, err := call(ctx)
+		if err == nil {
+			return res, append(attempts, Attempt{Latency: latency(res)}), nil
+		}
+		attempts = append(attempts, Attempt{Latency: time.Since(start), Err: err})
+
+		if attempt >= policy.MaxAttempts || !policy.retryable(err) || ctx.Err() != nil {
+			return zero, attempts, err
+		}
+
+		slog.Debug("retrying failed call", "attempt", attempt, "error", err.Error())
+		if err := sleep(ctx, policy.backoff(attempt)); err != nil {
+			return zero, attempts, err
+		}
+	}
+}
+
+// sleep waits for a given duration unless context is cancelled.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// call returns a call measuring a single prompt within timeout. Providers
+// which pace requests are waited for before the timeout starts, so that
+// waiting in a queue never fails a call.
+func (e *Evaluator) call(p *prompt.Prompt) func(context.Context) (*provider.Metric, error) {
+	return func(ctx context.Context) (*provider.Metric, error) {
+		if r, ok := e.provider.(provider.Reserver); ok {
+			var err error
+			if ctx, err = r.Reserve(ctx, e.model, p); err != nil {
+				return nil, err
+			}
+		}
+
+		return withTimeout(ctx, e.timeout, func(ctx context.Context) (*provider.Metric, error) {
+			return e.provider.Measure(ctx, e.model, p)
+		})
+	}
+}
+
+// withTimeout makes a call within timeout, zero means no limit. A call
+// which doesn't finish in time fails with ErrTimeout.
+func withTimeout[T any](ctx context.Context, timeout time.Duration, call func(context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return call(ctx)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	res, err := call(callCtx)
+	if err != nil && ctx.Err() == nil && callCtx.Err() != nil {
+		var zero T
+		return zero, fmt.Errorf("%w after %s", ErrTimeout, timeout)
+	}
+
+	return res, err
+}
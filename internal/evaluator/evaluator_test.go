@@ -0,0 +1,557 @@
+package evaluator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+)
+
+// fakeProvider replies with completions in order, cycling through them.
+// Contents of sent prompts are recorded, delay holds each reply back.
+// Errors, if any, are returned by the first calls. Prompts which declare
+// tools are answered by a call of the first one. System messages marked
+// for caching are remembered and reported as cached when sent again.
+// Completions of prompts without tools are streamed with firstToken, if set.
+type fakeProvider struct {
+	completions []string
+	calls       int
+	sent        []string
+	delay       time.Duration
+	errs        []error
+	cached      map[string]bool
+	firstToken  time.Duration
+}
+
+func (s *fakeProvider) Name() provider.ModelProvider { return "Fake" }
+
+func (s *fakeProvider) GetLLMModels(filter string) []*provider.Model { return nil }
+
+func (s *fakeProvider) VerifyAccess() bool { return true }
+
+func (s *fakeProvider) Send(message string, to *provider.Model) (*provider.Response, error) {
+	return s.SendPrompt(context.Background(), &prompt.Prompt{Content: message}, to)
+}
+
+func (s *fakeProvider) SendPrompt(ctx context.Context, p *prompt.Prompt, to *provider.Model) (*provider.Response, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.sent = append(s.sent, p.Content)
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return nil, err
+	}
+
+	res := &provider.Response{Completion: s.completions[s.calls%len(s.completions)]}
+	s.calls++
+
+	// Call the first declared tool instead of completing.
+	if len(p.Tools) > 0 {
+		res.Completion = ""
+		res.ToolCalls = []prompt.ToolCall{{Name: p.Tools[0].Name, Arguments: "{}"}}
+	}
+
+	if p.CacheSystem {
+		if s.cached[p.System] {
+			res.CachedInputTokens = len(p.System) / 4
+		}
+		if s.cached == nil {
+			s.cached = map[string]bool{}
+		}
+		s.cached[p.System] = true
+	}
+
+	return res, nil
+}
+
+func (s *fakeProvider) Measure(ctx context.Context, model *provider.Model, p *prompt.Prompt) (*provider.Metric, error) {
+	res, err := s.SendPrompt(ctx, p, model)
+	if err != nil {
+		return nil, err
+	}
+	m := &provider.Metric{Model: model, Response: res}
+	if len(p.Tools) == 0 {
+		m.FirstToken = s.firstToken
+	}
+	return m, nil
+}
+
+var fakeModel = &provider.Model{ID: "fake", Name: "Fake"}
+
+func TestEvaluateSuccessRate(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water.", "", "I can't help with that."}}
+	expect := &prompt.Expectation{Type: prompt.ExpectContains, Value: "water"}
+	prompts := []*prompt.Prompt{
+		{Content: "a", Expect: expect},
+		{Content: "b"},
+		{Content: "c", Expect: expect},
+	}
+
+	res, err := NewEvaluator(p, fakeModel, prompts...).Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Samples) != 3 {
+		t.Fatalf("expected 3 samples, got %d", len(res.Samples))
+	}
+
+	if !res.Samples[0].Passed() || res.Samples[1].Passed() || res.Samples[2].Passed() {
+		t.Errorf("unexpected checks: %v, %v, %v", res.Samples[0].Check, res.Samples[1].Check, res.Samples[2].Check)
+	}
+
+	if rate := res.SuccessRate(); rate < 0.33 || rate > 0.34 {
+		t.Errorf("expected success rate 1/3, got %f", rate)
+	}
+}
+
+func TestEvaluateCancelled(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).Evaluate(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancellation, got %v", err)
+	}
+
+	if p.calls != 0 {
+		t.Errorf("expected no completed calls, got %d", p.calls)
+	}
+}
+
+func TestEvaluateProgress(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+
+	var progress []Progress
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithSampleSize(3).
+		WithProgress(func(pr Progress) { progress = append(progress, pr) }).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(progress) != 3 {
+		t.Fatalf("expected progress after each of 3 samples, got %d", len(progress))
+	}
+
+	for i, pr := range progress {
+		if pr.Done != i+1 || pr.Total != 3 || pr.Sample == nil {
+			t.Errorf("unexpected progress %d: %+v", i, pr)
+		}
+	}
+}
+
+func TestEvaluatePromptModeUnique(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+	prompts := []*prompt.Prompt{{Content: "a"}, {Content: "b"}}
+
+	res, err := NewEvaluator(p, fakeModel, prompts...).
+		WithSampleSize(5).
+		WithPromptMode(PromptModeUnique).
+		WithWarmup(1).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Warm-up call is made but not sampled.
+	if want := []string{"a", "a", "b", "a", "b", "a"}; !slices.Equal(p.sent, want) {
+		t.Errorf("expected prompts %v, got %v", want, p.sent)
+	}
+
+	if len(res.Samples) != 5 {
+		t.Errorf("expected 5 samples, got %d", len(res.Samples))
+	}
+}
+
+func TestEvaluatePromptModeCache(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+	prompts := []*prompt.Prompt{{Content: "a"}, {Content: "b"}}
+
+	res, err := NewEvaluator(p, fakeModel, prompts...).
+		WithSampleSize(3).
+		WithPromptMode(PromptModeCache).
+		WithWarmup(2).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Warm-up is skipped, so the first sample writes the cache.
+	if want := []string{"a", "b", "a"}; !slices.Equal(p.sent, want) {
+		t.Errorf("expected prompts %v, got %v", want, p.sent)
+	}
+
+	cache := res.Cache()
+	if cache == nil {
+		t.Fatal("expected cache result")
+	}
+	if len(cache.Cached) != 2 || cache.Hits != 2 || cache.CachedTokens == 0 {
+		t.Errorf("expected 2 cached samples with hits, got %+v", cache)
+	}
+
+	if prompts[0].CacheSystem || prompts[0].System != "" {
+		t.Error("expected original prompts to stay unchanged")
+	}
+}
+
+func TestEvaluatePromptModeCacheWithoutSystemRole(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+	model := &provider.Model{ID: "titan", Name: "Titan", Family: provider.ModelFamilyTitan}
+
+	res, err := NewEvaluator(p, model, &prompt.Prompt{Content: "a"}).
+		WithSampleSize(2).
+		WithPromptMode(PromptModeCache).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sent := range p.sent {
+		if !strings.HasPrefix(sent, "Reference handbook") || !strings.HasSuffix(sent, "\na") {
+			t.Fatalf("expected prefix in user turn, got %.40q", sent)
+		}
+	}
+	if cache := res.Cache(); cache == nil || len(cache.Cached) != 1 {
+		t.Errorf("expected cache result of 2 samples, got %+v", cache)
+	}
+}
+
+func TestEvaluationCacheNotInCacheMode(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+
+	res, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithSampleSize(3).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cache := res.Cache(); cache != nil {
+		t.Errorf("expected no cache result, got %+v", cache)
+	}
+}
+
+func TestEvaluatePromptModeTools(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+	tool := []prompt.Tool{{Name: "get_weather"}}
+	prompts := []*prompt.Prompt{
+		{Content: "a"},
+		{Content: "b", Tools: tool},
+		{Content: "c", Tools: tool, Expect: &prompt.Expectation{Type: prompt.ExpectToolCall, Value: "get_time"}},
+	}
+
+	res, err := NewEvaluator(p, fakeModel, prompts...).
+		WithSampleSize(4).
+		WithPromptMode(PromptModeTools).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"b", "c", "b", "c"}; !slices.Equal(p.sent, want) {
+		t.Errorf("expected prompts %v, got %v", want, p.sent)
+	}
+
+	// Calls of get_weather don't meet expectation of get_time.
+	if res.SuccessRate() != 0.5 {
+		t.Errorf("expected success rate 0.5, got %v", res.SuccessRate())
+	}
+	if len(res.ToolLatency()) != 4 || len(res.TextLatency()) != 0 {
+		t.Errorf("expected 4 tool samples, got %d tool and %d text", len(res.ToolLatency()), len(res.TextLatency()))
+	}
+}
+
+func TestEvaluateFirstToken(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}, firstToken: time.Millisecond, errs: []error{provider.ErrMalformedResponse}}
+
+	res, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithSampleSize(3).
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 1}).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The failed sample has no time to first token.
+	want := []time.Duration{time.Millisecond, time.Millisecond}
+	if got := res.FirstToken(); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestEvaluatePromptModeToolsWithoutTools(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithPromptMode(PromptModeTools).
+		Evaluate(context.Background())
+	if !errors.Is(err, ErrNoTools) {
+		t.Errorf("expected ErrNoTools, got %v", err)
+	}
+}
+
+func TestEvaluatePromptModeStructured(t *testing.T) {
+	p := &fakeProvider{completions: []string{`{"color": "blue"}`, "Blue.", `{"color": 1}`, "```json\n{\"color\": \"red\"}\n```"}}
+	schema := json.RawMessage(`{"type":"object","properties":{"color":{"type":"string"}},"required":["color"]}`)
+	prompts := []*prompt.Prompt{{Content: "a"}, {Content: "b", ResponseSchema: schema}}
+
+	res, err := NewEvaluator(p, fakeModel, prompts...).
+		WithSampleSize(4).
+		WithPromptMode(PromptModeStructured).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first pair starts with structured output, the second with free text.
+	var structured []bool
+	for _, s := range res.Samples {
+		structured = append(structured, len(s.Prompt.ResponseSchema) > 0)
+	}
+	if want := []bool{true, false, false, true}; !slices.Equal(structured, want) {
+		t.Errorf("expected structured samples %v, got %v", want, structured)
+	}
+
+	r := res.Structured()
+	if r == nil || len(r.Structured) != 2 || len(r.FreeText) != 2 {
+		t.Fatalf("expected 2 structured and 2 free text samples, got %+v", r)
+	}
+	// JSON in code fences is valid only once extracted.
+	if r.ValidityRate() != 0.5 || r.ExtractedRate() != 1 {
+		t.Errorf("expected validity rate 0.5 and extracted rate 1, got %v and %v", r.ValidityRate(), r.ExtractedRate())
+	}
+	if res.SuccessRate() != 0.75 {
+		t.Errorf("expected success rate 0.75, got %v", res.SuccessRate())
+	}
+}
+
+func TestEvaluatePromptModeStructuredWithoutSchema(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithPromptMode(PromptModeStructured).
+		Evaluate(context.Background())
+	if !errors.Is(err, ErrNoSchema) {
+		t.Errorf("expected ErrNoSchema, got %v", err)
+	}
+}
+
+func TestEvaluatePromptModeVision(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Red."}}
+	image := []prompt.Image{{MediaType: "image/png", Data: []byte("png")}}
+	vision := &provider.Model{ID: "fake-vision", Name: "Fake Vision", Vision: true}
+
+	res, err := NewEvaluator(p, vision, &prompt.Prompt{Content: "a", Images: image}).
+		WithSampleSize(4).
+		WithPromptMode(PromptModeVision).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := res.Vision()
+	if r == nil || len(r.Image) != 2 || len(r.Text) != 2 {
+		t.Fatalf("expected 2 image and 2 text samples, got %+v", r)
+	}
+}
+
+func TestEvaluateImagesWithoutVision(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Red."}}
+	image := []prompt.Image{{MediaType: "image/png", Data: []byte("png")}}
+
+	text := &prompt.Prompt{Content: "a"}
+	withImage := &prompt.Prompt{Content: "b", Images: image}
+
+	// Prompts with images are left out outside of vision mode, prompts of
+	// the evaluator stay as configured.
+	e := NewEvaluator(p, fakeModel, text, withImage).
+		WithSampleSize(2).
+		WithPromptMode(PromptModeUnique)
+	res, err := e.Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.sent, []string{"a", "a"}) || res.Vision() != nil {
+		t.Errorf("expected text prompts only, got %v", p.sent)
+	}
+	if !slices.Equal(e.prompts, []*prompt.Prompt{text, withImage}) {
+		t.Errorf("expected evaluator prompts to stay unchanged, got %v", e.prompts)
+	}
+
+	p.sent = nil
+	for _, mode := range []PromptMode{PromptModeVision, PromptModeAuto} {
+		prompts := []*prompt.Prompt{withImage}
+		if mode == PromptModeVision {
+			prompts = append(prompts, text)
+		}
+
+		_, err := NewEvaluator(p, fakeModel, prompts...).
+			WithPromptMode(mode).
+			Evaluate(context.Background())
+		if !errors.Is(err, ErrNoVision) {
+			t.Errorf("expected ErrNoVision in %s mode, got %v", mode, err)
+		}
+	}
+	if len(p.sent) != 0 {
+		t.Errorf("expected no calls, got %d", len(p.sent))
+	}
+}
+
+func TestEvaluateTimeout(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}, delay: time.Second}
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithTimeout(10 * time.Millisecond).
+		Evaluate(context.Background())
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+}
+
+func TestEvaluateNegativeWarmup(t *testing.T) {
+	p := &fakeProvider{completions: []string{"Water."}}
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithWarmup(-1).
+		Evaluate(context.Background())
+	if !errors.Is(err, ErrWarmup) {
+		t.Errorf("expected warm-up error, got %v", err)
+	}
+}
+
+func TestEvaluateRetries(t *testing.T) {
+	throttled := &provider.Error{Class: provider.ErrThrottled, Err: errors.New("429")}
+	p := &fakeProvider{completions: []string{"Water."}, errs: []error{throttled, throttled}}
+
+	res, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Retryable: []error{provider.ErrThrottled}}).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attempts := res.Samples[0].Attempts
+	if len(attempts) != 3 || attempts[0].Err == nil || attempts[1].Err == nil || attempts[2].Err != nil {
+		t.Errorf("expected two failed attempts and a successful one, got %+v", attempts)
+	}
+
+	if res.Retries() != 2 {
+		t.Errorf("expected 2 retries, got %d", res.Retries())
+	}
+}
+
+func TestEvaluateRetriesExhausted(t *testing.T) {
+	throttled := &provider.Error{Class: provider.ErrThrottled, Err: errors.New("429")}
+	p := &fakeProvider{completions: []string{"Water."}, errs: []error{throttled, throttled}}
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond, Retryable: []error{provider.ErrThrottled}}).
+		Evaluate(context.Background())
+	if !errors.Is(err, provider.ErrThrottled) {
+		t.Errorf("expected throttled error, got %v", err)
+	}
+}
+
+func TestEvaluateNoRetryOfPermanentError(t *testing.T) {
+	denied := &provider.Error{Class: provider.ErrAccessDenied, Err: errors.New("403")}
+	p := &fakeProvider{completions: []string{"Water."}, errs: []error{denied}}
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}).
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Retryable: []error{provider.ErrThrottled}}).
+		Evaluate(context.Background())
+	if !errors.Is(err, provider.ErrAccessDenied) || len(p.sent) != 1 {
+		t.Errorf("expected a single failed call, got %d calls and %v", len(p.sent), err)
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	p := RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+
+	for retry, limit := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 5: 300 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if d := p.backoff(retry); d <= 0 || d > limit {
+				t.Fatalf("expected backoff of retry %d within (0, %s], got %s", retry, limit, d)
+			}
+		}
+	}
+}
+
+func TestEvaluatePartialFailure(t *testing.T) {
+	denied := &provider.Error{Class: provider.ErrAccessDenied, Err: errors.New("403")}
+	p := &fakeProvider{completions: []string{"Water."}, errs: []error{denied}}
+	prompts := []*prompt.Prompt{{Content: "a"}, {Content: "b"}, {Content: "c"}, {Content: "d"}}
+
+	var progress []Progress
+	res, err := NewEvaluator(p, fakeModel, prompts...).
+		WithProgress(func(pr Progress) { progress = append(progress, pr) }).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(res.Samples) != 4 || len(res.Latency) != 3 || len(res.Responses) != 3 {
+		t.Fatalf("expected 4 samples with 3 successes, got %d samples and %d latencies", len(res.Samples), len(res.Latency))
+	}
+
+	if failed := res.Samples[0]; failed.Succeeded() || failed.Passed() || !errors.Is(failed.Err, provider.ErrAccessDenied) {
+		t.Errorf("expected the first sample to fail, got %+v", failed)
+	}
+
+	if rate := res.ErrorRate(); rate != 0.25 {
+		t.Errorf("expected error rate 0.25, got %f", rate)
+	}
+
+	if rate := res.SuccessRate(); rate != 0.75 {
+		t.Errorf("expected success rate 0.75, got %f", rate)
+	}
+
+	if len(progress) != 4 {
+		t.Errorf("expected progress after each of 4 samples, got %d", len(progress))
+	}
+}
+
+func TestEvaluateAllFailed(t *testing.T) {
+	denied := &provider.Error{Class: provider.ErrAccessDenied, Err: errors.New("403")}
+	p := &fakeProvider{completions: []string{"Water."}, errs: []error{denied, denied}}
+
+	_, err := NewEvaluator(p, fakeModel, &prompt.Prompt{Content: "a"}, &prompt.Prompt{Content: "b"}).
+		Evaluate(context.Background())
+	if !errors.Is(err, ErrAllFailed) || !errors.Is(err, provider.ErrAccessDenied) {
+		t.Errorf("expected all samples to fail with access denied, got %v", err)
+	}
+}
+
+func TestEvaluationReasoning(t *testing.T) {
+	sample := func(output, reasoning int) *Sample {
+		return &Sample{Metric: &provider.Metric{Response: &provider.Response{OutputTokens: output, ReasoningTokens: reasoning}}}
+	}
+
+	e := &Evaluation{Samples: []*Sample{sample(10, 0), sample(30, 20)}}
+	if r := e.Reasoning(); r == nil || r.Reasoning != 10 || r.Visible != 10 {
+		t.Errorf("expected 10 reasoning and 10 visible tokens on average, got %+v", r)
+	}
+
+	e = &Evaluation{Samples: []*Sample{sample(10, 0), {Err: provider.ErrServer}}}
+	if r := e.Reasoning(); r != nil {
+		t.Errorf("expected no reasoning, got %+v", r)
+	}
+}
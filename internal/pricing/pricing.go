@@ -0,0 +1,175 @@
+// Package pricing estimates cost of model calls from token counts.
+package pricing
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+)
+
+// DefaultOutputTokens is an assumed number of output tokens per call used
+// for pre-flight estimates when model has no measured output yet. It is
+// deliberately generous, overestimating is safer than underestimating.
+const DefaultOutputTokens = 256
+
+// Price of a model in USD per one million tokens.
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Cost returns cost in USD of a call with given token counts.
+func (p Price) Cost(inputTokens, outputTokens int) float64 {
+	return (float64(inputTokens)*p.Input + float64(outputTokens)*p.Output) / 1_000_000
+}
+
+// Table maps provider and model ID onto model price.
+type Table map[provider.ModelProvider]map[string]Price
+
+// Default returns a copy of built-in price table. Prices are list prices
+// at the time of writing and may be outdated, override them in config.
+func Default() Table {
+	t := make(Table, len(defaultPrices))
+	for p, models := range defaultPrices {
+		t[p] = maps.Clone(models)
+	}
+	return t
+}
+
+// Merge overrides prices of the table with provided prices.
+func (t Table) Merge(overrides Table) Table {
+	for p, models := range overrides {
+		if t[p] == nil {
+			t[p] = make(map[string]Price, len(models))
+		}
+		maps.Copy(t[p], models)
+	}
+	return t
+}
+
+// Lookup returns price of a model, false if model has no price.
+func (t Table) Lookup(m *provider.Model) (Price, bool) {
+	price, ok := t[m.Provider][m.ID]
+	return price, ok
+}
+
+// Cost returns cost of a single call of a model. Token counts reported by
+// provider are used, if provider didn't report them they are estimated from
+// prompt and completion. Returns false if model has no price.
+func (t Table) Cost(m *provider.Model, p *prompt.Prompt, res *provider.Response) (float64, bool) {
+	price, ok := t.Lookup(m)
+	if !ok {
+		return 0, false
+	}
+
+	input, output := res.InputTokens, res.OutputTokens
+	if input == 0 && output == 0 {
+		input, output = EstimatePromptTokens(p), EstimateTokens(res.Completion)
+	}
+
+	return price.Cost(input, output), true
+}
+
+// EstimateTokens roughly estimates token count of a text, assuming four
+// characters per token which holds well enough for English.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// EstimatePromptTokens estimates input token count of a prompt including
+// its system message and prior turns.
+func EstimatePromptTokens(p *prompt.Prompt) int {
+	n := EstimateTokens(p.System) + EstimateTokens(p.Content)
+	for _, m := range p.Messages {
+		n += EstimateTokens(m.Content)
+	}
+	return n
+}
+
+// Format formats cost in USD.
+func Format(cost float64) string {
+	if cost >= 1 {
+		return fmt.Sprintf("$%.2f", cost)
+	}
+	return fmt.Sprintf("$%.4f", cost)
+}
+
+var defaultPrices = Table{
+	provider.ModelProviderOpenAI: {
+		"gpt-4-1106-preview":     {Input: 10, Output: 30},
+		"gpt-3.5-turbo":          {Input: 0.5, Output: 1.5},
+		"gpt-3.5-turbo-0125":     {Input: 0.5, Output: 1.5},
+		"o1-mini":                {Input: 1.1, Output: 4.4},
+		"o1-mini-2024-09-12":     {Input: 1.1, Output: 4.4},
+		"o1-2024-12-17":          {Input: 15, Output: 60},
+		"gpt-3.5-turbo-16k":      {Input: 3, Output: 4},
+		"o1":                     {Input: 15, Output: 60},
+		"o1-preview-2024-09-12":  {Input: 15, Output: 60},
+		"o1-preview":             {Input: 15, Output: 60},
+		"gpt-4":                  {Input: 30, Output: 60},
+		"gpt-4-0613":             {Input: 30, Output: 60},
+		"chatgpt-4o-latest":      {Input: 5, Output: 15},
+		"gpt-4o-2024-08-06":      {Input: 2.5, Output: 10},
+		"gpt-4o":                 {Input: 2.5, Output: 10},
+		"gpt-3.5-turbo-1106":     {Input: 1, Output: 2},
+		"gpt-4-turbo-2024-04-09": {Input: 10, Output: 30},
+		"gpt-4-turbo":            {Input: 10, Output: 30},
+		"gpt-4-turbo-preview":    {Input: 10, Output: 30},
+		"gpt-4o-2024-05-13":      {Input: 5, Output: 15},
+		"gpt-4o-2024-11-20":      {Input: 2.5, Output: 10},
+		"gpt-4o-mini-2024-07-18": {Input: 0.15, Output: 0.6},
+		"gpt-4o-mini":            {Input: 0.15, Output: 0.6},
+		"gpt-4-0125-preview":     {Input: 10, Output: 30},
+		"text-embedding-3-small": {Input: 0.02},
+		"text-embedding-3-large": {Input: 0.13},
+	},
+
+	provider.ModelProviderBedrock: {
+		"mistral.mistral-large-2402-v1:0":              {Input: 4, Output: 12},
+		"mistral.mistral-small-2402-v1:0":              {Input: 1, Output: 3},
+		"meta.llama3-8b-instruct-v1:0":                 {Input: 0.3, Output: 0.6},
+		"meta.llama3-70b-instruct-v1:0":                {Input: 2.65, Output: 3.5},
+		"cohere.command-text-v14":                      {Input: 1.5, Output: 2},
+		"cohere.command-r-v1:0":                        {Input: 0.5, Output: 1.5},
+		"cohere.command-r-plus-v1:0":                   {Input: 3, Output: 15},
+		"cohere.command-light-text-v14":                {Input: 0.3, Output: 0.6},
+		"ai21.jamba-1-5-large-v1:0":                    {Input: 2, Output: 8},
+		"ai21.jamba-1-5-mini-v1:0":                     {Input: 0.2, Output: 0.4},
+		"ai21.j2-mid":                                  {Input: 12.5, Output: 12.5},
+		"ai21.j2-mid-v1":                               {Input: 12.5, Output: 12.5},
+		"ai21.j2-ultra":                                {Input: 18.8, Output: 18.8},
+		"amazon.nova-pro-v1:0":                         {Input: 0.8, Output: 3.2},
+		"amazon.nova-lite-v1:0":                        {Input: 0.06, Output: 0.24},
+		"amazon.nova-micro-v1:0":                       {Input: 0.035, Output: 0.14},
+		"amazon.titan-text-premier-v1:0":               {Input: 0.5, Output: 1.5},
+		"amazon.titan-text-lite-v1":                    {Input: 0.15, Output: 0.2},
+		"amazon.titan-text-express-v1":                 {Input: 0.2, Output: 0.6},
+		"anthropic.claude-instant-v1":                  {Input: 0.8, Output: 2.4},
+		"anthropic.claude-v2:1":                        {Input: 8, Output: 24},
+		"anthropic.claude-v2":                          {Input: 8, Output: 24},
+		"us.anthropic.claude-3-haiku-20240307-v1:0":    {Input: 0.25, Output: 1.25},
+		"us.anthropic.claude-3-sonnet-20240229-v1:0":   {Input: 3, Output: 15},
+		"us.anthropic.claude-3-5-haiku-20241022-v1:0":  {Input: 0.8, Output: 4},
+		"us.anthropic.claude-3-5-sonnet-20240620-v1:0": {Input: 3, Output: 15},
+		"us.anthropic.claude-3-5-sonnet-20241022-v2:0": {Input: 3, Output: 15},
+		"amazon.titan-embed-text-v1":                   {Input: 0.1},
+		"amazon.titan-embed-text-v2:0":                 {Input: 0.02},
+		"cohere.embed-english-v3":                      {Input: 0.1},
+		"cohere.embed-multilingual-v3":                 {Input: 0.1},
+	},
+
+	provider.ModelProviderGroq: {
+		"gemma2-9b-it":                  {Input: 0.2, Output: 0.2},
+		"llama-3.3-70b-versatile":       {Input: 0.59, Output: 0.79},
+		"llama-3.1-8b-instant":          {Input: 0.05, Output: 0.08},
+		"llama-guard-3-8b":              {Input: 0.2, Output: 0.2},
+		"llama3-70b-8192":               {Input: 0.59, Output: 0.79},
+		"llama3-8b-8192":                {Input: 0.05, Output: 0.08},
+		"mixtral-8x7b-32768":            {Input: 0.24, Output: 0.24},
+		"deepseek-r1-distill-llama-70b": {Input: 0.75, Output: 0.99},
+		"llama-3.2-1b-preview":          {Input: 0.04, Output: 0.04},
+		"llama-3.2-3b-preview":          {Input: 0.06, Output: 0.06},
+	},
+}
@@ -0,0 +1,54 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+)
+
+func TestPriceCost(t *testing.T) {
+	p := Price{Input: 2.5, Output: 10}
+
+	if c := p.Cost(1_000_000, 0); c != 2.5 {
+		t.Errorf("expected $2.5 for 1M input tokens, got %f", c)
+	}
+
+	if c := p.Cost(1000, 100); math.Abs(c-0.0035) > 1e-12 {
+		t.Errorf("expected $0.0035, got %f", c)
+	}
+}
+
+func TestTableMergeAndLookup(t *testing.T) {
+	gpt := &provider.Model{ID: "gpt-4o", Provider: provider.ModelProviderOpenAI}
+	custom := &provider.Model{ID: "custom", Provider: provider.ModelProviderOpenAI}
+
+	table := Default().Merge(Table{
+		provider.ModelProviderOpenAI: {"gpt-4o": {Input: 1, Output: 2}},
+	})
+
+	if p, ok := table.Lookup(gpt); !ok || p.Input != 1 || p.Output != 2 {
+		t.Errorf("expected overridden price, got %+v", p)
+	}
+
+	if _, ok := table.Lookup(custom); ok {
+		t.Error("model without price should not be found")
+	}
+
+	if p, _ := Default().Lookup(gpt); p.Input != 2.5 {
+		t.Error("merge should not modify default prices")
+	}
+}
+
+func TestEstimatePromptTokens(t *testing.T) {
+	p := &prompt.Prompt{
+		System:   "1234",
+		Content:  "12345678",
+		Messages: []prompt.Message{{Role: prompt.RoleUser, Content: "12"}},
+	}
+
+	if n := EstimatePromptTokens(p); n != 4 {
+		t.Errorf("expected 4 tokens, got %d", n)
+	}
+}
@@ -0,0 +1,56 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cachePrefixSections is a number of sections of a shared prefix. Providers
+// cache prefixes of at least 1024 tokens, some models only of 2048 tokens,
+// so the prefix is comfortably longer.
+const cachePrefixSections = 100
+
+// CachePrefix returns a long system message to be shared by prompts to
+// measure prompt caching. Session makes the prefix unique, so that the
+// first call of a run is never served from cache of a previous run.
+func CachePrefix(session string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Reference handbook, session %s. Use it only if a question refers to it.\n\n", session)
+	for i := 1; i <= cachePrefixSections; i++ {
+		fmt.Fprintf(&b,
+			"Section %d. Station %d records temperature, pressure and humidity every %d minutes and reports deviations above %d percent to the operator on duty.\n",
+			i, i*7%97, i%15+1, i%40+10)
+	}
+
+	return b.String()
+}
+
+// WithCachedPrefix returns a copy of the prompt which system message starts
+// with a given prefix marked for caching.
+func (p *Prompt) WithCachedPrefix(prefix string) *Prompt {
+	c := *p
+	c.System = prefix
+	if p.System != "" {
+		c.System += "\n" + p.System
+	}
+	c.CacheSystem = true
+
+	return &c
+}
+
+// WithUserPrefix returns a copy of the prompt which first user turn starts
+// with a given prefix, for models which have no system message. Such
+// prefixes are cached automatically, if at all, so nothing is marked.
+func (p *Prompt) WithUserPrefix(prefix string) *Prompt {
+	c := *p
+	for i, m := range p.Messages {
+		if m.Role == RoleUser {
+			c.Messages = append([]Message(nil), p.Messages...)
+			c.Messages[i].Content = prefix + "\n" + m.Content
+			return &c
+		}
+	}
+	c.Content = prefix + "\n" + p.Content
+
+	return &c
+}
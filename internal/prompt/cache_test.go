@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"testing"
+)
+
+func TestCachePrefix(t *testing.T) {
+	a, b := CachePrefix("a"), CachePrefix("b")
+	if a == b {
+		t.Error("expected prefixes of different sessions to differ")
+	}
+
+	// Roughly four characters per token, providers need at least 2048.
+	if len(a)/4 < 2048 {
+		t.Errorf("expected prefix of at least 2048 tokens, got ~%d", len(a)/4)
+	}
+}
+
+func TestWithCachedPrefix(t *testing.T) {
+	p := &Prompt{Content: "hi", System: "Be brief."}
+
+	c := p.WithCachedPrefix("prefix")
+	if !c.CacheSystem || c.System != "prefix\nBe brief." || c.Content != "hi" {
+		t.Errorf("unexpected prompt with prefix: %+v", c)
+	}
+
+	if p.CacheSystem || p.System != "Be brief." {
+		t.Error("expected original prompt to stay intact")
+	}
+
+	if c := (&Prompt{Content: "hi"}).WithCachedPrefix("prefix"); c.System != "prefix" {
+		t.Errorf("unexpected system message without original one: %q", c.System)
+	}
+}
+
+func TestWithUserPrefix(t *testing.T) {
+	c := (&Prompt{Content: "hi"}).WithUserPrefix("prefix")
+	if c.CacheSystem || c.System != "" || c.Content != "prefix\nhi" {
+		t.Errorf("unexpected prompt with prefix: %+v", c)
+	}
+
+	p := &Prompt{Content: "bye", Messages: []Message{{Role: RoleUser, Content: "hi"}, {Role: RoleAssistant, Content: "hey"}}}
+	c = p.WithUserPrefix("prefix")
+	if c.Messages[0].Content != "prefix\nhi" || c.Content != "bye" {
+		t.Errorf("expected prefix in the first user turn, got %+v", c)
+	}
+	if p.Messages[0].Content != "hi" {
+		t.Error("expected original prompt to stay intact")
+	}
+}
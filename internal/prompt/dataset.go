@@ -0,0 +1,435 @@
+package prompt
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pvlbzn/latai/internal/schema"
+)
+
+var (
+	ErrDatasetFormat  = errors.New("unsupported dataset format")
+	ErrDatasetRecord  = errors.New("malformed dataset record")
+	ErrDatasetContent = errors.New("dataset record has no content")
+)
+
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single conversation turn.
+type Message struct {
+	// Role of the author of the message, either RoleUser or RoleAssistant.
+	Role string `json:"role"`
+
+	// Content of the message.
+	Content string `json:"content"`
+}
+
+// datasetRecord is a single line of a JSONL dataset. CSV datasets are
+// mapped onto the same structure.
+type datasetRecord struct {
+	ID             string          `json:"id"`
+	Content        string          `json:"content"`
+	System         string          `json:"system"`
+	Messages       []Message       `json:"messages"`
+	Metadata       map[string]any  `json:"metadata"`
+	Expect         *Expectation    `json:"expect"`
+	Tools          []Tool          `json:"tools"`
+	ResponseSchema json.RawMessage `json:"response_schema"`
+
+	// Images are paths of image files, relative to the dataset.
+	Images []string `json:"images"`
+}
+
+// isDataset reports whether a file name has a dataset extension.
+func isDataset(name string) bool {
+	switch filepath.Ext(name) {
+	case ".jsonl", ".csv":
+		return true
+	default:
+		return false
+	}
+}
+
+// LoadDataset loads prompts from a JSONL or CSV dataset file, one prompt
+// per line. Every record must have content, either as `content` field or
+// as the last user message of `messages`. Fields `id`, `system`, `messages`,
+// `metadata`, `expect`, `tools`, `response_schema` and `images` are optional.
+// Images are paths of files relative to the dataset.
+//
+// Schemas may only use keywords supported by package schema.
+//
+// CSV datasets must have a header. Columns `id`, `content` and `system` map
+// onto fields of the same name, `messages`, `metadata`, `expect`, `tools`,
+// `response_schema` and `images` hold JSON encoded values, and any other
+// column is added to metadata.
+func LoadDataset(path string) ([]*Prompt, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*datasetRecord
+	switch filepath.Ext(path) {
+	case ".jsonl":
+		records, err = readJSONL(f)
+	case ".csv":
+		records, err = readCSV(f)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrDatasetFormat, filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Base(path)
+	prompts := make([]*Prompt, 0, len(records))
+	for i, r := range records {
+		p, err := r.toPrompt(filepath.Dir(path))
+		if err != nil {
+			return nil, fmt.Errorf("%s record %d: %w", name, i+1, err)
+		}
+
+		if p.ID == "" {
+			p.ID = fmt.Sprintf("%s:%d", name, i+1)
+		}
+		p.Description = "Dataset prompt " + p.ID
+
+		prompts = append(prompts, p)
+	}
+
+	return prompts, nil
+}
+
+func readJSONL(r io.Reader) ([]*datasetRecord, error) {
+	var records []*datasetRecord
+
+	scanner := bufio.NewScanner(r)
+	// Production prompts easily exceed default 64KB line limit.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var rec datasetRecord
+		if err := json.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrDatasetRecord, line, err)
+		}
+		records = append(records, &rec)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func readCSV(r io.Reader) ([]*datasetRecord, error) {
+	reader := csv.NewReader(r)
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrDatasetRecord, err)
+	}
+
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := rows[0]
+	records := make([]*datasetRecord, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		rec := &datasetRecord{Metadata: map[string]any{}}
+
+		for col, value := range row {
+			switch key := strings.TrimSpace(strings.ToLower(header[col])); key {
+			case "id":
+				rec.ID = value
+			case "content":
+				rec.Content = value
+			case "system":
+				rec.System = value
+			case "messages":
+				if value == "" {
+					continue
+				}
+				if err := json.Unmarshal([]byte(value), &rec.Messages); err != nil {
+					return nil, fmt.Errorf("%w: row %d: messages: %v", ErrDatasetRecord, i+2, err)
+				}
+			case "metadata":
+				if value == "" {
+					continue
+				}
+				if err := json.Unmarshal([]byte(value), &rec.Metadata); err != nil {
+					return nil, fmt.Errorf("%w: row %d: metadata: %v", ErrDatasetRecord, i+2, err)
+				}
+			case "expect":
+				if value == "" {
+					continue
+				}
+				if err := json.Unmarshal([]byte(value), &rec.Expect); err != nil {
+					return nil, fmt.Errorf("%w: row %d: expect: %v", ErrDatasetRecord, i+2, err)
+				}
+			case "tools":
+				if value == "" {
+					continue
+				}
+				if err := json.Unmarshal([]byte(value), &rec.Tools); err != nil {
+					return nil, fmt.Errorf("%w: row %d: tools: %v", ErrDatasetRecord, i+2, err)
+				}
+			case "images":
+				if value == "" {
+					continue
+				}
+				if err := json.Unmarshal([]byte(value), &rec.Images); err != nil {
+					return nil, fmt.Errorf("%w: row %d: images: %v", ErrDatasetRecord, i+2, err)
+				}
+			case "response_schema":
+				if value == "" {
+					continue
+				}
+				if !json.Valid([]byte(value)) {
+					return nil, fmt.Errorf("%w: row %d: response_schema is not valid JSON", ErrDatasetRecord, i+2)
+				}
+				rec.ResponseSchema = json.RawMessage(value)
+			default:
+				if value != "" {
+					rec.Metadata[key] = value
+				}
+			}
+		}
+
+		records = append(records, rec)
+	}
+
+	return records, nil
+}
+
+// toPrompt converts dataset record into a prompt. System messages found in
+// messages are merged into system prompt, and if content is not set the last
+// user message becomes content. Images are loaded relative to a given
+// directory.
+func (r *datasetRecord) toPrompt(dir string) (*Prompt, error) {
+	p := &Prompt{
+		Type:           PromptTypeDataset,
+		ID:             r.ID,
+		Content:        r.Content,
+		System:         r.System,
+		Expect:         r.Expect,
+		Tools:          r.Tools,
+		ResponseSchema: r.ResponseSchema,
+	}
+
+	var systems []string
+	if p.System != "" {
+		systems = append(systems, p.System)
+	}
+
+	for _, m := range r.Messages {
+		if m.Role == RoleSystem {
+			systems = append(systems, m.Content)
+			continue
+		}
+		p.Messages = append(p.Messages, m)
+	}
+	p.System = strings.Join(systems, "\n\n")
+
+	if p.Content == "" && len(p.Messages) > 0 && p.Messages[len(p.Messages)-1].Role == RoleUser {
+		p.Content = p.Messages[len(p.Messages)-1].Content
+		p.Messages = p.Messages[:len(p.Messages)-1]
+	}
+
+	if p.Content == "" {
+		return nil, ErrDatasetContent
+	}
+
+	if err := checkSchemas(p); err != nil {
+		return nil, err
+	}
+
+	for _, path := range r.Images {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		img, err := LoadImage(path)
+		if err != nil {
+			return nil, err
+		}
+		p.Images = append(p.Images, img)
+	}
+
+	if len(r.Metadata) > 0 {
+		p.Metadata = make(map[string]string, len(r.Metadata))
+		for k, v := range r.Metadata {
+			p.Metadata[k] = fmt.Sprint(v)
+		}
+	}
+
+	return p, nil
+}
+
+// checkSchemas returns schema.ErrInvalidSchema if response schema,
+// expectation schema or tool parameters of a prompt use keywords which
+// can't be validated, such prompts would pass invalid documents.
+func checkSchemas(p *Prompt) error {
+	if len(p.ResponseSchema) > 0 {
+		if _, err := schema.Parse(p.ResponseSchema); err != nil {
+			return fmt.Errorf("response_schema: %w", err)
+		}
+	}
+
+	if p.Expect != nil && p.Expect.Type == ExpectJSONSchema {
+		if _, err := schema.Parse(p.Expect.Schema); err != nil {
+			return fmt.Errorf("expect: %w", err)
+		}
+	}
+
+	for _, t := range p.Tools {
+		if _, err := schema.Parse(t.Schema()); err != nil {
+			return fmt.Errorf("tool %s parameters: %w", t.Name, err)
+		}
+	}
+
+	return nil
+}
+
+type SampleMode string
+
+const (
+	// SampleAll keeps all prompts.
+	SampleAll SampleMode = ""
+	// SampleFirst keeps first N prompts.
+	SampleFirst SampleMode = "first"
+	// SampleRandom keeps N random prompts.
+	SampleRandom SampleMode = "random"
+	// SampleStratified keeps N random prompts distributed proportionally
+	// across groups of the same tag.
+	SampleStratified SampleMode = "stratified"
+)
+
+// DefaultStratifyTag is a metadata key used for stratified sampling
+// when no key provided.
+const DefaultStratifyTag = "tag"
+
+// Sampling defines how a large set of prompts is narrowed down.
+type Sampling struct {
+	Mode SampleMode `json:"mode,omitempty"`
+
+	// N is a number of prompts to keep. Zero or value greater than the
+	// number of prompts keeps all of them.
+	N int `json:"n,omitempty"`
+
+	// Seed of random and stratified sampling, same seed yields same sample.
+	Seed int64 `json:"seed,omitempty"`
+
+	// Tag is a metadata key to stratify by, DefaultStratifyTag if empty.
+	Tag string `json:"tag,omitempty"`
+}
+
+// Sample returns a subset of prompts according to sampling. Original
+// order of prompts is preserved for all modes.
+func Sample(prompts []*Prompt, s Sampling) []*Prompt {
+	if s.Mode == SampleAll || s.N <= 0 || s.N >= len(prompts) {
+		return prompts
+	}
+
+	rnd := rand.New(rand.NewSource(s.Seed))
+
+	var picked []int
+	switch s.Mode {
+	case SampleFirst:
+		return prompts[:s.N]
+
+	case SampleRandom:
+		picked = rnd.Perm(len(prompts))[:s.N]
+
+	case SampleStratified:
+		tag := s.Tag
+		if tag == "" {
+			tag = DefaultStratifyTag
+		}
+		picked = stratify(prompts, s.N, tag, rnd)
+
+	default:
+		return prompts
+	}
+
+	sort.Ints(picked)
+	res := make([]*Prompt, 0, len(picked))
+	for _, i := range picked {
+		res = append(res, prompts[i])
+	}
+
+	return res
+}
+
+// stratify picks n prompt indices, allocating picks to each tag group
+// proportionally to its size using the largest remainder method.
+func stratify(prompts []*Prompt, n int, tag string, rnd *rand.Rand) []int {
+	groups := map[string][]int{}
+	for i, p := range prompts {
+		key := p.Metadata[tag]
+		groups[key] = append(groups[key], i)
+	}
+
+	// Iterate groups in a stable order so that seed defines the sample.
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	type share struct {
+		key       string
+		quota     int
+		remainder float64
+	}
+
+	shares := make([]*share, 0, len(keys))
+	allocated := 0
+	for _, k := range keys {
+		exact := float64(n) * float64(len(groups[k])) / float64(len(prompts))
+		quota := int(exact)
+		shares = append(shares, &share{key: k, quota: quota, remainder: exact - float64(quota)})
+		allocated += quota
+	}
+
+	byRemainder := make([]*share, len(shares))
+	copy(byRemainder, shares)
+	sort.SliceStable(byRemainder, func(i, j int) bool {
+		return byRemainder[i].remainder > byRemainder[j].remainder
+	})
+	for i := 0; allocated < n; i++ {
+		byRemainder[i%len(byRemainder)].quota++
+		allocated++
+	}
+
+	var picked []int
+	for _, sh := range shares {
+		group := groups[sh.key]
+		for _, j := range rnd.Perm(len(group))[:min(sh.quota, len(group))] {
+			picked = append(picked, group[j])
+		}
+	}
+
+	return picked
+}
@@ -0,0 +1,138 @@
+package prompt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pvlbzn/latai/internal/schema"
+)
+
+func writeDataset(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadDatasetJSONL(t *testing.T) {
+	path := writeDataset(t, "prod.jsonl", `
+{"id": "a", "content": "Hello", "system": "Be brief.", "metadata": {"tag": "chat", "len": 5}}
+{"messages": [{"role": "system", "content": "Be kind."}, {"role": "user", "content": "Hi"}, {"role": "assistant", "content": "Hey"}, {"role": "user", "content": "Bye"}]}
+`)
+
+	prompts, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 prompts, got %d", len(prompts))
+	}
+
+	p := prompts[0]
+	if p.ID != "a" || p.Content != "Hello" || p.System != "Be brief." || p.Type != PromptTypeDataset {
+		t.Errorf("unexpected first prompt: %+v", p)
+	}
+	if p.Metadata["tag"] != "chat" || p.Metadata["len"] != "5" {
+		t.Errorf("unexpected metadata: %v", p.Metadata)
+	}
+
+	p = prompts[1]
+	if p.ID != "prod.jsonl:2" {
+		t.Errorf("expected generated ID, got %q", p.ID)
+	}
+	if p.Content != "Bye" || p.System != "Be kind." || len(p.Messages) != 2 {
+		t.Errorf("unexpected second prompt: %+v", p)
+	}
+}
+
+func TestLoadDatasetJSONLWithoutContent(t *testing.T) {
+	path := writeDataset(t, "bad.jsonl", `{"id": "a", "messages": [{"role": "assistant", "content": "Hey"}]}`)
+
+	if _, err := LoadDataset(path); err == nil {
+		t.Fatal("expected error for record without content")
+	}
+}
+
+func TestLoadDatasetUnsupportedSchema(t *testing.T) {
+	records := []string{
+		`{"content": "Age?", "response_schema": {"type": "integer", "minimum": 0}}`,
+		`{"content": "Code?", "response_schema": {"type": "object", "properties": {"code": {"type": "string", "pattern": "^[A-Z]+$"}}}}`,
+		`{"content": "Name?", "expect": {"type": "json_schema", "schema": {"anyOf": [{"type": "string"}, {"type": "null"}]}}}`,
+		`{"content": "Weather?", "tools": [{"name": "get_weather", "parameters": {"type": "object", "properties": {"days": {"type": "integer", "maximum": 7}}}}]}`,
+	}
+
+	for _, r := range records {
+		path := writeDataset(t, "schema.jsonl", r)
+		if _, err := LoadDataset(path); !errors.Is(err, schema.ErrInvalidSchema) {
+			t.Errorf("%s: expected ErrInvalidSchema, got %v", r, err)
+		}
+	}
+}
+
+func TestLoadDatasetCSV(t *testing.T) {
+	path := writeDataset(t, "prod.csv", `id,content,system,tag
+1,"Hello, world",Be brief.,chat
+2,Summarize,,docs
+`)
+
+	prompts, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 prompts, got %d", len(prompts))
+	}
+
+	if prompts[0].Content != "Hello, world" || prompts[0].System != "Be brief." || prompts[0].Metadata["tag"] != "chat" {
+		t.Errorf("unexpected first prompt: %+v", prompts[0])
+	}
+
+	if prompts[1].System != "" || prompts[1].Metadata["tag"] != "docs" {
+		t.Errorf("unexpected second prompt: %+v", prompts[1])
+	}
+}
+
+func TestSample(t *testing.T) {
+	var prompts []*Prompt
+	for i := 0; i < 10; i++ {
+		tag := "a"
+		if i >= 8 {
+			tag = "b"
+		}
+		prompts = append(prompts, &Prompt{Content: "p", Metadata: map[string]string{"tag": tag}})
+	}
+
+	if res := Sample(prompts, Sampling{}); len(res) != 10 {
+		t.Errorf("expected all prompts, got %d", len(res))
+	}
+
+	if res := Sample(prompts, Sampling{Mode: SampleFirst, N: 3}); len(res) != 3 || res[0] != prompts[0] {
+		t.Errorf("expected first 3 prompts, got %d", len(res))
+	}
+
+	r1 := Sample(prompts, Sampling{Mode: SampleRandom, N: 4, Seed: 7})
+	r2 := Sample(prompts, Sampling{Mode: SampleRandom, N: 4, Seed: 7})
+	if len(r1) != 4 {
+		t.Fatalf("expected 4 prompts, got %d", len(r1))
+	}
+	for i := range r1 {
+		if r1[i] != r2[i] {
+			t.Fatal("same seed should yield same sample")
+		}
+	}
+
+	res := Sample(prompts, Sampling{Mode: SampleStratified, N: 5, Seed: 1})
+	count := map[string]int{}
+	for _, p := range res {
+		count[p.Metadata["tag"]]++
+	}
+	if count["a"] != 4 || count["b"] != 1 {
+		t.Errorf("expected 4/1 stratified split, got %v", count)
+	}
+}
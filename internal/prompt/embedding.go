@@ -0,0 +1,32 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// embeddingWords is a vocabulary of generated embedding inputs.
+var embeddingWords = strings.Fields(`
+	station records temperature pressure humidity wind river valley morning
+	evening report operator sensor network signal battery solar panel field
+	harvest market price freight train harbor bridge tunnel engine library
+	archive letter museum garden forest glacier desert island mountain cloud`)
+
+// EmbeddingInputs returns n texts of a given number of words to measure
+// embedding models. Each text starts with its number counted from offset,
+// so that inputs of different calls don't repeat and aren't served from
+// a cache.
+func EmbeddingInputs(n, words, offset int) []string {
+	inputs := make([]string, n)
+	for i := range inputs {
+		id := offset + i
+		text := make([]string, 0, words)
+		text = append(text, fmt.Sprintf("#%d", id))
+		for j := 1; j < words; j++ {
+			text = append(text, embeddingWords[(id*7+j*13)%len(embeddingWords)])
+		}
+		inputs[i] = strings.Join(text, " ")
+	}
+
+	return inputs
+}
@@ -0,0 +1,28 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbeddingInputs(t *testing.T) {
+	inputs := EmbeddingInputs(3, 16, 0)
+	if len(inputs) != 3 {
+		t.Fatalf("expected 3 inputs, got %d", len(inputs))
+	}
+
+	for _, in := range inputs {
+		if n := len(strings.Fields(in)); n != 16 {
+			t.Errorf("expected 16 words, got %d in %q", n, in)
+		}
+	}
+
+	next := EmbeddingInputs(3, 16, 3)
+	seen := map[string]bool{}
+	for _, in := range append(inputs, next...) {
+		if seen[in] {
+			t.Errorf("input %q repeats", in)
+		}
+		seen[in] = true
+	}
+}
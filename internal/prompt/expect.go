@@ -0,0 +1,103 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pvlbzn/latai/internal/schema"
+)
+
+var (
+	ErrEmptyCompletion   = errors.New("completion is empty")
+	ErrExpectationNotMet = errors.New("expectation not met")
+	ErrExpectationType   = errors.New("unsupported expectation type")
+)
+
+type ExpectType string
+
+const (
+	// ExpectExact requires completion to be equal to value, surrounding
+	// whitespace is ignored.
+	ExpectExact ExpectType = "exact"
+	// ExpectContains requires completion to contain value, case-insensitive.
+	ExpectContains ExpectType = "contains"
+	// ExpectRegex requires completion to match regular expression value.
+	ExpectRegex ExpectType = "regex"
+	// ExpectJSONSchema requires completion to be JSON document valid
+	// against schema.
+	ExpectJSONSchema ExpectType = "json_schema"
+	// ExpectToolCall requires a call of a declared tool, of the one named
+	// by value if set. See Prompt.CheckToolCalls.
+	ExpectToolCall ExpectType = "tool_call"
+)
+
+// Expectation declares what a correct completion of a prompt looks like.
+type Expectation struct {
+	Type   ExpectType      `json:"type"`
+	Value  string          `json:"value,omitempty"`
+	Schema json.RawMessage `json:"schema,omitempty"`
+}
+
+// Check returns nil if completion meets the expectation. Empty completion
+// never meets an expectation. Nil expectation only requires completion to
+// be non-empty.
+func (e *Expectation) Check(completion string) error {
+	if strings.TrimSpace(completion) == "" {
+		return ErrEmptyCompletion
+	}
+
+	if e == nil {
+		return nil
+	}
+
+	switch e.Type {
+	case ExpectExact:
+		if strings.TrimSpace(completion) != strings.TrimSpace(e.Value) {
+			return fmt.Errorf("%w: expected exactly %q", ErrExpectationNotMet, e.Value)
+		}
+
+	case ExpectContains:
+		if !strings.Contains(strings.ToLower(completion), strings.ToLower(e.Value)) {
+			return fmt.Errorf("%w: expected to contain %q", ErrExpectationNotMet, e.Value)
+		}
+
+	case ExpectRegex:
+		re, err := regexp.Compile(e.Value)
+		if err != nil {
+			return fmt.Errorf("%w: invalid regex: %v", ErrExpectationType, err)
+		}
+		if !re.MatchString(completion) {
+			return fmt.Errorf("%w: expected to match %q", ErrExpectationNotMet, e.Value)
+		}
+
+	case ExpectJSONSchema:
+		err := schema.Validate(e.Schema, []byte(schema.ExtractJSON(completion)))
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrExpectationNotMet, err)
+		}
+
+	case ExpectToolCall:
+		return fmt.Errorf("%w: expected a tool call, prompt declares no tools", ErrExpectationNotMet)
+
+	default:
+		return fmt.Errorf("%w: %s", ErrExpectationType, e.Type)
+	}
+
+	return nil
+}
+
+var singleWordPattern = regexp.MustCompile(`(?i)respond with a single word:\s*"([^"]+)"`)
+
+// inferExpectation derives a built-in expectation from prompt content,
+// e.g. `Respond with a single word: "water".` expects "water".
+func inferExpectation(content string) *Expectation {
+	match := singleWordPattern.FindStringSubmatch(content)
+	if match == nil {
+		return nil
+	}
+
+	return &Expectation{Type: ExpectContains, Value: match[1]}
+}
@@ -0,0 +1,46 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExpectationCheck(t *testing.T) {
+	tests := []struct {
+		expect     *Expectation
+		completion string
+		err        error
+	}{
+		{nil, "anything", nil},
+		{nil, "  ", ErrEmptyCompletion},
+		{&Expectation{Type: ExpectExact, Value: "water"}, " water\n", nil},
+		{&Expectation{Type: ExpectExact, Value: "water"}, "Water.", ErrExpectationNotMet},
+		{&Expectation{Type: ExpectContains, Value: "water"}, "Water.", nil},
+		{&Expectation{Type: ExpectContains, Value: "water"}, "I cannot help", ErrExpectationNotMet},
+		{&Expectation{Type: ExpectRegex, Value: `^\d+$`}, "42", nil},
+		{&Expectation{Type: ExpectRegex, Value: `^\d+$`}, "forty two", ErrExpectationNotMet},
+		{&Expectation{Type: ExpectRegex, Value: `(`}, "42", ErrExpectationType},
+		{&Expectation{Type: ExpectJSONSchema, Schema: []byte(`{"type": "object", "required": ["a"]}`)}, "```json\n{\"a\": 1}\n```", nil},
+		{&Expectation{Type: ExpectJSONSchema, Schema: []byte(`{"type": "object", "required": ["a"]}`)}, `{"b": 1}`, ErrExpectationNotMet},
+		{&Expectation{Type: "unknown"}, "water", ErrExpectationType},
+	}
+
+	for i, tt := range tests {
+		if err := tt.expect.Check(tt.completion); !errors.Is(err, tt.err) {
+			t.Errorf("case %d: expected %v, got %v", i, tt.err, err)
+		}
+	}
+}
+
+func TestDefaultPromptsHaveExpectations(t *testing.T) {
+	prompts, err := loadDefaultPrompts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range prompts {
+		if p.Expect == nil || p.Expect.Type != ExpectContains || p.Expect.Value == "" {
+			t.Errorf("%s should have a built-in expectation, got %+v", p.Description, p.Expect)
+		}
+	}
+}
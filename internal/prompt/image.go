@@ -0,0 +1,145 @@
+package prompt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrImageFormat = errors.New("unsupported image format")
+
+// imageMediaTypes maps image file extensions onto media types accepted by
+// providers.
+var imageMediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// Image is an image attached to a prompt.
+type Image struct {
+	// Path of the image file, empty for generated images.
+	Path string
+
+	// MediaType of the image, such as `image/png`.
+	MediaType string
+
+	Data []byte
+}
+
+// LoadImage reads an image file, its format is detected by extension.
+func LoadImage(path string) (Image, error) {
+	mediaType, ok := imageMediaTypes[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return Image{}, fmt.Errorf("%w: %s", ErrImageFormat, filepath.Ext(path))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{Path: path, MediaType: mediaType, Data: data}, nil
+}
+
+// Format returns image format without media type prefix, such as `png`.
+func (i Image) Format() string {
+	return strings.TrimPrefix(i.MediaType, "image/")
+}
+
+// Base64 returns image data encoded as standard base64.
+func (i Image) Base64() string {
+	return base64.StdEncoding.EncodeToString(i.Data)
+}
+
+// DataURL returns image as a data URL.
+func (i Image) DataURL() string {
+	return fmt.Sprintf("data:%s;base64,%s", i.MediaType, i.Base64())
+}
+
+// WithoutImages returns a copy of the prompt without attached images.
+func (p *Prompt) WithoutImages() *Prompt {
+	c := *p
+	c.Images = nil
+
+	return &c
+}
+
+// VisionPrompts returns prompts which attach images, or default vision
+// prompts if there are none.
+func VisionPrompts(prompts []*Prompt) []*Prompt {
+	var res []*Prompt
+	for _, p := range prompts {
+		if len(p.Images) > 0 {
+			res = append(res, p)
+		}
+	}
+
+	if len(res) == 0 {
+		return defaultVisionPrompts()
+	}
+	return res
+}
+
+// defaultVisionPrompts are prompts about generated images, so that no
+// image files are shipped.
+func defaultVisionPrompts() []*Prompt {
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	red := color.RGBA{R: 220, A: 255}
+	blue := color.RGBA{R: 40, G: 90, B: 200, A: 255}
+
+	square := newImage(512, 512, white)
+	fill(square, image.Rect(156, 156, 356, 356), red)
+
+	chart := newImage(512, 512, white)
+	for i, height := range []int{200, 320, 140, 260} {
+		x := 56 + i*110
+		fill(chart, image.Rect(x, 456-height, x+70, 456), blue)
+	}
+
+	return []*Prompt{
+		{
+			Type:        PromptTypeDefault,
+			Description: "Default vision prompt square",
+			Content:     `What is the color of the square in the image? Respond with a single word: the color.`,
+			Images:      []Image{encodePNG(square)},
+			Expect:      &Expectation{Type: ExpectContains, Value: "red"},
+		},
+		{
+			Type:        PromptTypeDefault,
+			Description: "Default vision prompt chart",
+			Content:     `How many bars are in the chart? Respond with a single number.`,
+			Images:      []Image{encodePNG(chart)},
+			Expect:      &Expectation{Type: ExpectContains, Value: "4"},
+		},
+	}
+}
+
+func newImage(width, height int, background color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), background)
+
+	return img
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, &image.Uniform{C: c}, image.Point{}, draw.Src)
+}
+
+func encodePNG(img image.Image) Image {
+	var b bytes.Buffer
+	// Encoding into memory fails only on invalid image.
+	_ = png.Encode(&b, img)
+
+	return Image{MediaType: "image/png", Data: b.Bytes()}
+}
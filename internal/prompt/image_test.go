@@ -0,0 +1,56 @@
+package prompt
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDatasetImages(t *testing.T) {
+	path := writeDataset(t, "vision.jsonl", `{"content": "What is it?", "images": ["chart.png"]}`)
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "chart.png"), []byte("png"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	prompts, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := prompts[0].Images
+	if len(images) != 1 || images[0].MediaType != "image/png" || string(images[0].Data) != "png" {
+		t.Fatalf("unexpected images: %+v", images)
+	}
+	if images[0].DataURL() != "data:image/png;base64,cG5n" {
+		t.Errorf("unexpected data URL %q", images[0].DataURL())
+	}
+}
+
+func TestLoadImageFormat(t *testing.T) {
+	if _, err := LoadImage("scan.tiff"); !errors.Is(err, ErrImageFormat) {
+		t.Errorf("expected ErrImageFormat, got %v", err)
+	}
+}
+
+func TestDefaultVisionPrompts(t *testing.T) {
+	prompts := VisionPrompts([]*Prompt{{Content: "Hi"}})
+	if len(prompts) != 2 {
+		t.Fatalf("expected 2 default vision prompts, got %d", len(prompts))
+	}
+
+	for _, p := range prompts {
+		if _, err := png.Decode(bytes.NewReader(p.Images[0].Data)); err != nil {
+			t.Errorf("%s: %v", p.Description, err)
+		}
+		if p.WithoutImages().Images != nil || len(p.Images) == 0 {
+			t.Errorf("%s: expected copy without images", p.Description)
+		}
+		if !strings.Contains(p.Content, "single") || p.Expect == nil {
+			t.Errorf("%s: expected a checked short answer", p.Description)
+		}
+	}
+}
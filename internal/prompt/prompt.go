@@ -2,6 +2,7 @@ package prompt
 
 import (
 	"embed"
+	"encoding/json"
 	"errors"
 	"io/fs"
 	"log/slog"
@@ -15,6 +16,7 @@ type PromptType string
 const (
 	PromptTypeUser    PromptType = "user"
 	PromptTypeDefault PromptType = "default"
+	PromptTypeDataset PromptType = "dataset"
 )
 
 // Prompt structure which represents a single prompt.
@@ -27,14 +29,52 @@ type Prompt struct {
 
 	// Content of the prompt.
 	Content string
+
+	// ID optionally identifies a prompt, e.g. a record ID of a dataset.
+	ID string
+
+	// System is an optional system message.
+	System string
+
+	// Messages are optional prior conversation turns which precede Content.
+	Messages []Message
+
+	// Metadata is free form data attached to a prompt, e.g. dataset tags.
+	Metadata map[string]string
+
+	// Expect optionally declares what a correct completion looks like.
+	Expect *Expectation
+
+	// ResponseSchema optionally requests structured output, a JSON
+	// document valid against the schema.
+	ResponseSchema json.RawMessage
+
+	// Images are optionally attached to content.
+	Images []Image
+
+	// Tools are optionally declared for a model to call, such prompts are
+	// answered by tool calls.
+	Tools []Tool
+
+	// CacheSystem asks provider to cache system message, which then holds
+	// a long prefix shared by prompts. Providers which cache prefixes
+	// automatically ignore it.
+	CacheSystem bool
 }
 
 //go:embed prompts/*.prompt
 var defaultPrompts embed.FS
 
 // GetPrompts returns prompts for evaluation. Returns either user-defined prompts
-// from `~/.latai/prompts/*.prompt`, or default embedded prompts.
+// from `~/.latai/prompts/*.prompt` and `~/.latai/prompts/*.{jsonl,csv}` datasets,
+// or default embedded prompts.
 func GetPrompts() ([]*Prompt, error) {
+	return GetSampledPrompts(Sampling{})
+}
+
+// GetSampledPrompts works as GetPrompts, but narrows user-defined prompts down
+// using provided sampling. Default prompts are never sampled.
+func GetSampledPrompts(sampling Sampling) ([]*Prompt, error) {
 	dir := filepath.Join(os.Getenv("HOME"), ".latai", "prompts")
 
 	prompts, err := loadUserPrompts(dir)
@@ -42,10 +82,10 @@ func GetPrompts() ([]*Prompt, error) {
 		return loadDefaultPrompts()
 	}
 
-	return prompts, nil
+	return Sample(prompts, sampling), nil
 }
 
-// loadUserPrompts loads prompt files from a given directory.
+// loadUserPrompts loads prompt files and datasets from a given directory.
 func loadUserPrompts(dir string) ([]*Prompt, error) {
 	var prompts []*Prompt
 
@@ -58,7 +98,22 @@ func loadUserPrompts(dir string) ([]*Prompt, error) {
 	}
 
 	for _, file := range files {
-		if file.IsDir() || filepath.Ext(file.Name()) != ".prompt" {
+		if file.IsDir() {
+			continue
+		}
+
+		if isDataset(file.Name()) {
+			dataset, err := LoadDataset(filepath.Join(dir, file.Name()))
+			if err != nil {
+				slog.Error("failed to load dataset", "err", err, "file", file.Name())
+				continue
+			}
+
+			prompts = append(prompts, dataset...)
+			continue
+		}
+
+		if filepath.Ext(file.Name()) != ".prompt" {
 			continue
 		}
 
@@ -71,6 +126,7 @@ func loadUserPrompts(dir string) ([]*Prompt, error) {
 			Type:        PromptTypeUser,
 			Description: "User prompt " + file.Name(),
 			Content:     string(content),
+			Expect:      inferExpectation(string(content)),
 		})
 	}
 
@@ -102,6 +158,7 @@ func loadDefaultPrompts() ([]*Prompt, error) {
 			Type:        PromptTypeDefault,
 			Description: "Default prompt " + f.Name(),
 			Content:     string(content),
+			Expect:      inferExpectation(string(content)),
 		})
 	}
 
@@ -1,6 +1,10 @@
 package prompt
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -20,3 +24,37 @@ func TestGetPrompts(t *testing.T) {
 		t.Fatalf("prompts shouldn't be empty, got `p1=%d`, `p2=%d`, `p3=%d`", p1, p2, p3)
 	}
 }
+
+func TestGetSampledPrompts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := filepath.Join(home, ".latai", "prompts")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, fmt.Sprintf(`{"id": "p%d", "content": "Prompt %d"}`, i, i))
+	}
+	if err := os.WriteFile(filepath.Join(dir, "set.jsonl"), []byte(strings.Join(lines, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := GetSampledPrompts(Sampling{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 10 {
+		t.Fatalf("expected all 10 dataset prompts, got %d", len(all))
+	}
+
+	res, err := GetSampledPrompts(Sampling{Mode: SampleRandom, N: 3, Seed: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 3 || res[0].Type != PromptTypeDataset {
+		t.Fatalf("expected 3 sampled dataset prompts, got %d", len(res))
+	}
+}
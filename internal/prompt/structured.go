@@ -0,0 +1,97 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pvlbzn/latai/internal/schema"
+)
+
+// CheckStructured returns nil if completion is a JSON document valid
+// against response schema of the prompt, only surrounding whitespace is
+// ignored. Prompts without response schema pass.
+func (p *Prompt) CheckStructured(completion string) error {
+	return p.checkStructured(strings.TrimSpace(completion))
+}
+
+// CheckExtractedStructured is a lenient CheckStructured, it validates a
+// JSON document extracted from code fences or prose around it.
+func (p *Prompt) CheckExtractedStructured(completion string) error {
+	return p.checkStructured(schema.ExtractJSON(completion))
+}
+
+func (p *Prompt) checkStructured(document string) error {
+	if len(p.ResponseSchema) == 0 {
+		return nil
+	}
+
+	if err := schema.Validate(p.ResponseSchema, []byte(document)); err != nil {
+		return fmt.Errorf("%w: %v", ErrExpectationNotMet, err)
+	}
+	return nil
+}
+
+// WithStructuredInstruction returns a copy of the prompt which content asks
+// for a JSON document matching response schema. Families which can't
+// constrain decoding fall back onto it.
+func (p *Prompt) WithStructuredInstruction() *Prompt {
+	if len(p.ResponseSchema) == 0 {
+		return p
+	}
+
+	c := *p
+	c.Content = fmt.Sprintf(
+		"%s\n\nRespond only with a JSON document which matches this JSON Schema, without any other text:\n%s",
+		p.Content, p.ResponseSchema)
+
+	return &c
+}
+
+// WithoutResponseSchema returns a copy of the prompt which asks for a free
+// text completion.
+func (p *Prompt) WithoutResponseSchema() *Prompt {
+	c := *p
+	c.ResponseSchema = nil
+
+	return &c
+}
+
+// StructuredPrompts returns prompts which request structured output, or
+// default structured prompts if there are none.
+func StructuredPrompts(prompts []*Prompt) []*Prompt {
+	var res []*Prompt
+	for _, p := range prompts {
+		if len(p.ResponseSchema) > 0 {
+			res = append(res, p)
+		}
+	}
+
+	if len(res) == 0 {
+		return defaultStructuredPrompts()
+	}
+	return res
+}
+
+// defaultStructuredPrompts are prompts which are answered well both in
+// free text and as JSON.
+func defaultStructuredPrompts() []*Prompt {
+	return []*Prompt{
+		{
+			Type:        PromptTypeDefault,
+			Description: "Default structured prompt person",
+			Content:     "Extract the person from this sentence: Maria is 34 years old and lives in Porto.",
+			ResponseSchema: json.RawMessage(`{"type":"object","properties":{` +
+				`"name":{"type":"string"},"age":{"type":"integer"},"city":{"type":"string"}},` +
+				`"required":["name","age","city"],"additionalProperties":false}`),
+		},
+		{
+			Type:        PromptTypeDefault,
+			Description: "Default structured prompt colors",
+			Content:     "List the three primary colors of the RGB color model.",
+			ResponseSchema: json.RawMessage(`{"type":"object","properties":{` +
+				`"colors":{"type":"array","items":{"type":"string"},"minItems":3,"maxItems":3}},` +
+				`"required":["colors"],"additionalProperties":false}`),
+		},
+	}
+}
@@ -0,0 +1,67 @@
+package prompt
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckStructured(t *testing.T) {
+	p := defaultStructuredPrompts()[0]
+
+	if err := p.CheckStructured(" {\"name\": \"Maria\", \"age\": 34, \"city\": \"Porto\"}\n"); err != nil {
+		t.Errorf("expected valid document, got %v", err)
+	}
+	if err := p.CheckStructured(`{"name": "Maria", "age": "34", "city": "Porto"}`); !errors.Is(err, ErrExpectationNotMet) {
+		t.Errorf("expected mismatch, got %v", err)
+	}
+	if err := p.CheckStructured("Maria, 34, Porto"); !errors.Is(err, ErrExpectationNotMet) {
+		t.Errorf("expected invalid JSON, got %v", err)
+	}
+
+	if err := p.WithoutResponseSchema().CheckStructured("Maria, 34, Porto"); err != nil {
+		t.Errorf("expected free text to pass, got %v", err)
+	}
+}
+
+func TestCheckExtractedStructured(t *testing.T) {
+	p := defaultStructuredPrompts()[0]
+	fenced := "```json\n{\"name\": \"Maria\", \"age\": 34, \"city\": \"Porto\"}\n```"
+	prose := `Sure! {"name": "Maria", "age": 34, "city": "Porto"}`
+
+	for _, c := range []string{fenced, prose} {
+		if err := p.CheckStructured(c); !errors.Is(err, ErrExpectationNotMet) {
+			t.Errorf("expected %q to be invalid, got %v", c, err)
+		}
+		if err := p.CheckExtractedStructured(c); err != nil {
+			t.Errorf("expected %q to be valid once extracted, got %v", c, err)
+		}
+	}
+}
+
+func TestWithStructuredInstruction(t *testing.T) {
+	p := defaultStructuredPrompts()[0]
+
+	c := p.WithStructuredInstruction()
+	if !strings.HasPrefix(c.Content, p.Content) || !strings.Contains(c.Content, string(p.ResponseSchema)) {
+		t.Errorf("expected instruction with schema, got %q", c.Content)
+	}
+	if p.Content == c.Content {
+		t.Error("expected original prompt to stay unchanged")
+	}
+}
+
+func TestStructuredPrompts(t *testing.T) {
+	if res := StructuredPrompts([]*Prompt{{Content: "Hi"}}); len(res) != 2 {
+		t.Errorf("expected default structured prompts, got %d", len(res))
+	}
+
+	path := writeDataset(t, "structured.jsonl", `{"content": "Colors?", "response_schema": {"type": "array"}}`)
+	prompts, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := StructuredPrompts(prompts); len(res) != 1 || string(res[0].ResponseSchema) != `{"type": "array"}` {
+		t.Errorf("expected dataset prompt, got %v", res)
+	}
+}
@@ -0,0 +1,143 @@
+package prompt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/pvlbzn/latai/internal/schema"
+)
+
+var (
+	ErrNoToolCall  = errors.New("no tool call returned")
+	ErrUnknownTool = errors.New("unknown tool called")
+)
+
+// Tool is a function which a prompt declares for a model to call.
+type Tool struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Parameters is a JSON Schema of tool arguments, an object without
+	// properties if not set.
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+}
+
+// ToolCall is a call of a declared tool returned by a model.
+type ToolCall struct {
+	Name string
+
+	// Arguments are JSON encoded arguments of the call.
+	Arguments string
+}
+
+// noParameters is a schema of a tool without arguments.
+var noParameters = json.RawMessage(`{"type":"object","properties":{}}`)
+
+// Schema returns JSON Schema of tool arguments.
+func (t Tool) Schema() json.RawMessage {
+	if len(t.Parameters) == 0 {
+		return noParameters
+	}
+	return t.Parameters
+}
+
+// CheckToolCalls returns nil if a model called declared tools with
+// arguments valid against their schemas. Expectation of ExpectToolCall
+// type with a value also requires a call of the tool it names. Prompts
+// which declare tools are checked by their calls instead of completion.
+func (p *Prompt) CheckToolCalls(calls []ToolCall) error {
+	if len(calls) == 0 {
+		return ErrNoToolCall
+	}
+
+	for _, c := range calls {
+		tool, ok := p.tool(c.Name)
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownTool, c.Name)
+		}
+
+		args := c.Arguments
+		if args == "" {
+			args = "{}"
+		}
+		if err := schema.Validate(tool.Schema(), []byte(args)); err != nil {
+			return fmt.Errorf("%w: %s arguments: %v", ErrExpectationNotMet, c.Name, err)
+		}
+	}
+
+	if p.Expect != nil && p.Expect.Type == ExpectToolCall && p.Expect.Value != "" {
+		for _, c := range calls {
+			if c.Name == p.Expect.Value {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: expected a call of %s", ErrExpectationNotMet, p.Expect.Value)
+	}
+
+	return nil
+}
+
+func (p *Prompt) tool(name string) (Tool, bool) {
+	for _, t := range p.Tools {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Tool{}, false
+}
+
+// ToolPrompts returns prompts which declare tools, or default tool prompts
+// if there are none.
+func ToolPrompts(prompts []*Prompt) []*Prompt {
+	var res []*Prompt
+	for _, p := range prompts {
+		if len(p.Tools) > 0 {
+			res = append(res, p)
+		}
+	}
+
+	if len(res) == 0 {
+		return defaultToolPrompts()
+	}
+	return res
+}
+
+// defaultToolPrompts are prompts which are answered only by a tool call.
+func defaultToolPrompts() []*Prompt {
+	weather := Tool{
+		Name:        "get_weather",
+		Description: "Get current weather in a city.",
+		Parameters: json.RawMessage(`{"type":"object","properties":{` +
+			`"city":{"type":"string","description":"City name"},` +
+			`"unit":{"type":"string","enum":["celsius","fahrenheit"],"description":"Temperature unit"}},` +
+			`"required":["city"]}`),
+	}
+	convert := Tool{
+		Name:        "convert_currency",
+		Description: "Convert an amount of money from one currency into another.",
+		Parameters: json.RawMessage(`{"type":"object","properties":{` +
+			`"amount":{"type":"number","description":"Amount to convert"},` +
+			`"from":{"type":"string","description":"ISO code of source currency"},` +
+			`"to":{"type":"string","description":"ISO code of target currency"}},` +
+			`"required":["amount","from","to"]}`),
+	}
+	tools := []Tool{weather, convert}
+
+	return []*Prompt{
+		{
+			Type:        PromptTypeDefault,
+			Description: "Default tool prompt get_weather",
+			Content:     "What is the weather in Lisbon right now, in celsius?",
+			Tools:       tools,
+			Expect:      &Expectation{Type: ExpectToolCall, Value: weather.Name},
+		},
+		{
+			Type:        PromptTypeDefault,
+			Description: "Default tool prompt convert_currency",
+			Content:     "How much is 250 US dollars in euros?",
+			Tools:       tools,
+			Expect:      &Expectation{Type: ExpectToolCall, Value: convert.Name},
+		},
+	}
+}
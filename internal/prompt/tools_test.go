@@ -0,0 +1,62 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckToolCalls(t *testing.T) {
+	p := defaultToolPrompts()[0]
+
+	tests := []struct {
+		name  string
+		calls []ToolCall
+		want  error
+	}{
+		{"valid call", []ToolCall{{Name: "get_weather", Arguments: `{"city": "Lisbon", "unit": "celsius"}`}}, nil},
+		{"no call", nil, ErrNoToolCall},
+		{"unknown tool", []ToolCall{{Name: "get_time", Arguments: `{}`}}, ErrUnknownTool},
+		{"missing argument", []ToolCall{{Name: "get_weather", Arguments: `{"unit": "celsius"}`}}, ErrExpectationNotMet},
+		{"malformed arguments", []ToolCall{{Name: "get_weather", Arguments: `{"city": `}}, ErrExpectationNotMet},
+		{"other tool", []ToolCall{{Name: "convert_currency", Arguments: `{"amount": 1, "from": "USD", "to": "EUR"}`}}, ErrExpectationNotMet},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := p.CheckToolCalls(tt.calls)
+			if tt.want == nil && err != nil {
+				t.Errorf("expected no error, got %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestToolPrompts(t *testing.T) {
+	if res := ToolPrompts([]*Prompt{{Content: "Hi"}}); len(res) != 2 || len(res[0].Tools) == 0 {
+		t.Errorf("expected default tool prompts, got %d", len(res))
+	}
+
+	own := &Prompt{Content: "Hi", Tools: []Tool{{Name: "greet"}}}
+	if res := ToolPrompts([]*Prompt{{Content: "Hi"}, own}); len(res) != 1 || res[0] != own {
+		t.Errorf("expected only prompts with tools, got %v", res)
+	}
+}
+
+func TestLoadDatasetTools(t *testing.T) {
+	path := writeDataset(t, "tools.jsonl", `{"content": "Weather?", "tools": [{"name": "get_weather", "parameters": {"type": "object"}}], "expect": {"type": "tool_call"}}`)
+
+	prompts, err := LoadDataset(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(prompts[0].Tools) != 1 || prompts[0].Tools[0].Name != "get_weather" {
+		t.Errorf("unexpected tools: %+v", prompts[0].Tools)
+	}
+	if err := prompts[0].CheckToolCalls([]ToolCall{{Name: "get_weather"}}); err != nil {
+		t.Errorf("expected call without arguments to pass, got %v", err)
+	}
+}
@@ -5,12 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/service/bedrock"
 	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/pvlbzn/latai/internal/prompt"
 	"log/slog"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -19,9 +26,10 @@ const (
 )
 
 type Bedrock struct {
-	client  *bedrock.Client
-	runtime *bedrockruntime.Client
-	models  []Model
+	client          *bedrock.Client
+	runtime         *bedrockruntime.Client
+	models          []Model
+	embeddingModels []Model
 }
 
 // NewBedrock creates a new AWS Bedrock client with provided region and profile.
@@ -32,10 +40,13 @@ func NewBedrock(profile string, region string) (*Bedrock, error) {
 		profile, region = getAWSCredentials()
 	}
 
+	// SDK retries are disabled, they would be measured as a part of latency.
+	// Evaluator retries failed calls and records each attempt separately.
 	cfg, err := config.LoadDefaultConfig(
 		context.Background(),
 		config.WithRegion(region),
-		config.WithSharedConfigProfile(profile))
+		config.WithSharedConfigProfile(profile),
+		config.WithRetryer(func() aws.Retryer { return aws.NopRetryer{} }))
 	if err != nil {
 		return nil, err
 	}
@@ -65,8 +76,8 @@ func NewBedrock(profile string, region string) (*Bedrock, error) {
 		{ID: "ai21.j2-ultra", Name: "Jurassic-2 Ultra", Provider: ModelProviderBedrock, Vendor: ModelVendorAI21Labs, Family: ModelFamilyJurassic},
 
 		// Nova family.
-		{ID: "amazon.nova-pro-v1:0", Name: "Nova Pro", Provider: ModelProviderBedrock, Vendor: ModelVendorAmazon, Family: ModelFamilyNova},
-		{ID: "amazon.nova-lite-v1:0", Name: "Nova Lite", Provider: ModelProviderBedrock, Vendor: ModelVendorAmazon, Family: ModelFamilyNova},
+		{ID: "amazon.nova-pro-v1:0", Name: "Nova Pro", Provider: ModelProviderBedrock, Vendor: ModelVendorAmazon, Family: ModelFamilyNova, Vision: true},
+		{ID: "amazon.nova-lite-v1:0", Name: "Nova Lite", Provider: ModelProviderBedrock, Vendor: ModelVendorAmazon, Family: ModelFamilyNova, Vision: true},
 		{ID: "amazon.nova-micro-v1:0", Name: "Nova Micro", Provider: ModelProviderBedrock, Vendor: ModelVendorAmazon, Family: ModelFamilyNova},
 
 		//Titan family.
@@ -79,17 +90,28 @@ func NewBedrock(profile string, region string) (*Bedrock, error) {
 		{ID: "anthropic.claude-instant-v1", Name: "Claude Instant v1", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
 		{ID: "anthropic.claude-v2:1", Name: "Claude v2:1", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
 		{ID: "anthropic.claude-v2", Name: "Claude v2", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
-		{ID: "us.anthropic.claude-3-haiku-20240307-v1:0", Name: "Claude 3 Haiku", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
-		{ID: "us.anthropic.claude-3-sonnet-20240229-v1:0", Name: "Claude 3 Sonnet", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
+		{ID: "us.anthropic.claude-3-haiku-20240307-v1:0", Name: "Claude 3 Haiku", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude, Vision: true},
+		{ID: "us.anthropic.claude-3-sonnet-20240229-v1:0", Name: "Claude 3 Sonnet", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude, Vision: true},
 		{ID: "us.anthropic.claude-3-5-haiku-20241022-v1:0", Name: "Claude 3.5 Haiku", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
-		{ID: "us.anthropic.claude-3-5-sonnet-20240620-v1:0", Name: "Claude 3.5 Sonnet v1", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
-		{ID: "us.anthropic.claude-3-5-sonnet-20241022-v2:0", Name: "Claude 3.5 Sonnet v2", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
+		{ID: "us.anthropic.claude-3-5-sonnet-20240620-v1:0", Name: "Claude 3.5 Sonnet v1", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude, Vision: true},
+		{ID: "us.anthropic.claude-3-5-sonnet-20241022-v2:0", Name: "Claude 3.5 Sonnet v2", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude, Vision: true},
+	}
+
+	embeddingModels := []Model{
+		// Titan Embed family.
+		{ID: "amazon.titan-embed-text-v1", Name: "Titan Embeddings G1 - Text", Provider: ModelProviderBedrock, Vendor: ModelVendorAmazon, Family: ModelFamilyTitanEmbed, Dimensions: 1536},
+		{ID: "amazon.titan-embed-text-v2:0", Name: "Titan Text Embeddings V2", Provider: ModelProviderBedrock, Vendor: ModelVendorAmazon, Family: ModelFamilyTitanEmbed, Dimensions: 1024},
+
+		// Cohere Embed family.
+		{ID: "cohere.embed-english-v3", Name: "Embed English", Provider: ModelProviderBedrock, Vendor: ModelVendorCohere, Family: ModelFamilyCohereEmbed, Dimensions: 1024},
+		{ID: "cohere.embed-multilingual-v3", Name: "Embed Multilingual", Provider: ModelProviderBedrock, Vendor: ModelVendorCohere, Family: ModelFamilyCohereEmbed, Dimensions: 1024},
 	}
 
 	return &Bedrock{
-		client:  bedrock.NewFromConfig(cfg),
-		runtime: bedrockruntime.NewFromConfig(cfg),
-		models:  models,
+		client:          bedrock.NewFromConfig(cfg),
+		runtime:         bedrockruntime.NewFromConfig(cfg),
+		models:          models,
+		embeddingModels: embeddingModels,
 	}, nil
 }
 
@@ -128,23 +150,75 @@ func (s *Bedrock) VerifyAccess() bool {
 	return true
 }
 
-// GetLLMModels returns LLM models only which name matches filter.
-// Empty filter string returns all models unfiltered.
+// GetLLMModels returns LLM models only which name, ID, family, vendor
+// or provider matches filter. Empty filter string returns all models
+// unfiltered.
 func (s *Bedrock) GetLLMModels(filter string) []*Model {
 	return filterModels(s.models, filter)
 }
 
 // Send message.
 func (s *Bedrock) Send(message string, model *Model) (*Response, error) {
-	// Internally Send is a routing function which delegates actual
+	return s.SendPrompt(context.Background(), userPrompt(message), model)
+}
+
+// bedrockToolFamilies are model families which accept tools declared by
+// prompt in their native format.
+var bedrockToolFamilies = map[ModelFamily]bool{
+	ModelFamilyClaude:   true,
+	ModelFamilyNova:     true,
+	ModelFamilyCommandR: true,
+}
+
+// bedrockImageFamilies are model families which accept images attached to
+// prompt in their native format.
+var bedrockImageFamilies = map[ModelFamily]bool{
+	ModelFamilyClaude: true,
+	ModelFamilyNova:   true,
+}
+
+// bedrockStreamFamilies are model families which completions are streamed
+// when measured. Other families and prompts with tools are measured by full
+// completion.
+var bedrockStreamFamilies = map[ModelFamily]bool{
+	ModelFamilyClaude: true,
+	ModelFamilyNova:   true,
+	ModelFamilyTitan:  true,
+	ModelFamilyLlama3: true,
+}
+
+// SendPrompt sends prompt mapped onto model family's native format.
+func (s *Bedrock) SendPrompt(ctx context.Context, p *prompt.Prompt, model *Model) (*Response, error) {
+	return s.sendPrompt(ctx, p, model, nil)
+}
+
+// sendPrompt streams completion of a family from bedrockStreamFamilies if
+// onToken is set, it is called for each chunk which carries text.
+func (s *Bedrock) sendPrompt(ctx context.Context, p *prompt.Prompt, model *Model, onToken func()) (*Response, error) {
+	// Internally sendPrompt is a routing function which delegates actual
 	// computation to an appropriate vendor handler.
+	if !bedrockToolFamilies[model.Family] {
+		if err := requireNoTools(p, model); err != nil {
+			return nil, err
+		}
+	}
+	if !bedrockImageFamilies[model.Family] {
+		if err := requireNoImages(p, model); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bedrock families can't constrain decoding, structured output is
+	// requested by instruction.
+	p = p.WithStructuredInstruction()
+
 	switch model.Vendor {
 	case ModelVendorAmazon:
 		switch model.Family {
 		case ModelFamilyNova:
-			return s.runBedrockInferenceNovaFamily(message, model)
+			return s.runBedrockInferenceNovaFamily(ctx, p, model, onToken)
 		case ModelFamilyTitan:
-			return s.runBedrockInferenceTitanFamily(message, model)
+			return s.runBedrockInferenceTitanFamily(ctx, p, model, onToken)
 		default:
 			return nil, fmt.Errorf("unsupported model family %s", model.Family)
 		}
@@ -152,31 +226,31 @@ func (s *Bedrock) Send(message string, model *Model) (*Response, error) {
 	case ModelVendorAI21Labs:
 		switch model.Family {
 		case ModelFamilyJurassic:
-			return s.runBedrockInferenceJurassicFamily(message, model)
+			return s.runBedrockInferenceJurassicFamily(ctx, p, model)
 		case ModelFamilyJamba:
-			return s.runBedrockInferenceJambaFamily(message, model)
+			return s.runBedrockInferenceJambaFamily(ctx, p, model)
 		default:
 			return nil, fmt.Errorf("unsupported model family %s", model.Family)
 		}
 
 	case ModelVendorAnthropic:
-		return s.runBedrockInferenceClaudeFamily(message, model)
+		return s.runBedrockInferenceClaudeFamily(ctx, p, model, onToken)
 
 	case ModelVendorCohere:
 		switch model.Family {
 		case ModelFamilyCommand:
-			return s.runBedrockInferenceCommandFamily(message, model)
+			return s.runBedrockInferenceCommandFamily(ctx, p, model)
 		case ModelFamilyCommandR:
-			return s.runBedrockInferenceCommandRFamily(message, model)
+			return s.runBedrockInferenceCommandRFamily(ctx, p, model)
 		default:
 			return nil, fmt.Errorf("unsupported model family %s", model.Family)
 		}
 
 	case ModelVendorMeta:
-		return s.runBedrockInferenceLlama3Family(message, model)
+		return s.runBedrockInferenceLlama3Family(ctx, p, model, onToken)
 
 	case ModelVendorMistralAI:
-		return s.runBedrockInferenceMistralFamily(message, model)
+		return s.runBedrockInferenceMistralFamily(ctx, p, model)
 
 	default:
 		return nil, fmt.Errorf("unsupported model vendor: %s", model.Vendor)
@@ -201,9 +275,20 @@ type titanResponse struct {
 	} `json:"results"`
 }
 
-func (s *Bedrock) runBedrockInferenceTitanFamily(message string, model *Model) (*Response, error) {
+func titanCompletion(res titanResponse) (string, error) {
+	if len(res.Results) == 0 {
+		return "", errNoResults
+	}
+	return res.Results[0].OutputText, nil
+}
+
+func (s *Bedrock) runBedrockInferenceTitanFamily(ctx context.Context, p *prompt.Prompt, model *Model, onToken func()) (*Response, error) {
+	if err := requireNoSystem(p, model); err != nil {
+		return nil, err
+	}
+
 	data := &titanRequest{
-		InputText: message,
+		InputText: flattenTranscript(p, "User", "Bot"),
 		TextGenerationConfig: textGenerationConfig{
 			MaxTokenCount: 1024,
 			Temperature:   0.1,
@@ -212,51 +297,111 @@ func (s *Bedrock) runBedrockInferenceTitanFamily(message string, model *Model) (
 		},
 	}
 
-	parser := func(res titanResponse) string {
-		return res.Results[0].OutputText
+	if onToken != nil {
+		return streamBedrockInference(ctx, s, model, data, titanChunk, onToken)
 	}
 
-	return runBedrockInference(s, model, data, parser)
+	return runBedrockInference(ctx, s, model, data, titanCompletion)
 }
 
 type novaRequest struct {
-	Messages []novaMessage `json:"messages"`
+	System     []novaContent   `json:"system,omitempty"`
+	Messages   []novaMessage   `json:"messages"`
+	ToolConfig *novaToolConfig `json:"toolConfig,omitempty"`
+}
+
+// novaToolConfig declares tools in the same format as Converse API.
+type novaToolConfig struct {
+	Tools []novaTool `json:"tools"`
+}
+
+type novaTool struct {
+	ToolSpec novaToolSpec `json:"toolSpec"`
+}
+
+type novaToolSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	InputSchema struct {
+		JSON json.RawMessage `json:"json"`
+	} `json:"inputSchema"`
+}
+
+type novaToolUse struct {
+	ToolUseID string          `json:"toolUseId"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
 }
 
 type novaMessage struct {
-	Role    string `json:"role"`
-	Content []struct {
-		Text string `json:"text"`
-	} `json:"content"`
+	Role    string        `json:"role"`
+	Content []novaContent `json:"content"`
+}
+
+type novaContent struct {
+	Text       string          `json:"text,omitempty"`
+	CachePoint *novaCachePoint `json:"cachePoint,omitempty"`
+	ToolUse    *novaToolUse    `json:"toolUse,omitempty"`
+	Image      *novaImage      `json:"image,omitempty"`
+}
+
+type novaImage struct {
+	Format string `json:"format"`
+	Source struct {
+		Bytes string `json:"bytes"`
+	} `json:"source"`
+}
+
+// novaCachePoint marks the end of a prefix to be cached.
+type novaCachePoint struct {
+	Type string `json:"type"`
 }
 
 type novaResponse struct {
 	Output struct {
 		Message struct {
-			Content []struct {
-				Text string `json:"text"`
-			} `json:"content"`
+			Content []novaContent `json:"content"`
 		} `json:"message"`
 	} `json:"output"`
+	Usage struct {
+		CacheReadInputTokenCount  int `json:"cacheReadInputTokenCount"`
+		CacheWriteInputTokenCount int `json:"cacheWriteInputTokenCount"`
+	} `json:"usage"`
 }
 
-func (s *Bedrock) runBedrockInferenceNovaFamily(message string, model *Model) (*Response, error) {
-	data := &novaRequest{
-		Messages: []novaMessage{
-			{
-				Role: "user",
-				Content: []struct {
-					Text string `json:"text"`
-				}{{Text: message}},
-			},
-		},
+func (r novaResponse) cacheTokens() (int, int) {
+	return r.Usage.CacheReadInputTokenCount, r.Usage.CacheWriteInputTokenCount
+}
+
+func (r novaResponse) toolCalls() []prompt.ToolCall {
+	var res []prompt.ToolCall
+	for _, c := range r.Output.Message.Content {
+		if c.ToolUse != nil {
+			res = append(res, prompt.ToolCall{Name: c.ToolUse.Name, Arguments: string(c.ToolUse.Input)})
+		}
+	}
+
+	return res
+}
+
+func (s *Bedrock) runBedrockInferenceNovaFamily(ctx context.Context, p *prompt.Prompt, model *Model, onToken func()) (*Response, error) {
+	data := newNovaRequest(p)
+
+	// Text may follow or be missing along with tool calls.
+	parser := func(res novaResponse) (string, error) {
+		for _, c := range res.Output.Message.Content {
+			if c.Text != "" {
+				return c.Text, nil
+			}
+		}
+		return "", nil
 	}
 
-	parser := func(res novaResponse) string {
-		return res.Output.Message.Content[0].Text
+	if onToken != nil {
+		return streamBedrockInference(ctx, s, model, data, novaChunk, onToken)
 	}
 
-	return runBedrockInference(s, model, data, parser)
+	return runBedrockInference(ctx, s, model, data, parser)
 }
 
 type jurassicRequest struct {
@@ -274,19 +419,63 @@ type jurassicResponse struct {
 	} `json:"completions"`
 }
 
-func (s *Bedrock) runBedrockInferenceJurassicFamily(message string, model *Model) (*Response, error) {
+func jurassicCompletion(res jurassicResponse) (string, error) {
+	if len(res.Completions) == 0 {
+		return "", errNoResults
+	}
+	return res.Completions[0].Data.Text, nil
+}
+
+func newNovaRequest(p *prompt.Prompt) *novaRequest {
+	data := &novaRequest{}
+	if p.System != "" {
+		data.System = []novaContent{{Text: p.System}}
+		if p.CacheSystem {
+			data.System = append(data.System, novaContent{CachePoint: &novaCachePoint{Type: "default"}})
+		}
+	}
+	for _, t := range turns(p) {
+		data.Messages = append(data.Messages, novaMessage{
+			Role:    t.Role,
+			Content: []novaContent{{Text: t.Content}},
+		})
+	}
+	// Images precede text of the last user message.
+	if len(p.Images) > 0 {
+		last := &data.Messages[len(data.Messages)-1]
+		var content []novaContent
+		for _, img := range p.Images {
+			image := &novaImage{Format: img.Format()}
+			image.Source.Bytes = img.Base64()
+			content = append(content, novaContent{Image: image})
+		}
+		last.Content = append(content, last.Content...)
+	}
+	if len(p.Tools) > 0 {
+		data.ToolConfig = &novaToolConfig{}
+		for _, t := range p.Tools {
+			spec := novaToolSpec{Name: t.Name, Description: t.Description}
+			spec.InputSchema.JSON = t.Schema()
+			data.ToolConfig.Tools = append(data.ToolConfig.Tools, novaTool{ToolSpec: spec})
+		}
+	}
+
+	return data
+}
+
+func (s *Bedrock) runBedrockInferenceJurassicFamily(ctx context.Context, p *prompt.Prompt, model *Model) (*Response, error) {
+	if err := requireNoSystem(p, model); err != nil {
+		return nil, err
+	}
+
 	data := jurassicRequest{
-		Prompt:      message,
+		Prompt:      flattenTranscript(p, "User", "Assistant"),
 		MaxTokens:   1024,
 		Temperature: 0.5,
 		TopP:        0.5,
 	}
 
-	parser := func(res jurassicResponse) string {
-		return res.Completions[0].Data.Text
-	}
-
-	return runBedrockInference(s, model, data, parser)
+	return runBedrockInference(ctx, s, model, data, jurassicCompletion)
 }
 
 type jambaRequest struct {
@@ -306,80 +495,281 @@ type jambaResponse struct {
 	} `json:"choices"`
 }
 
-func (s *Bedrock) runBedrockInferenceJambaFamily(message string, model *Model) (*Response, error) {
-	data := &jambaRequest{
-		Messages: []jambaMessage{
-			{Role: "user", Content: message},
-		},
+func jambaCompletion(res jambaResponse) (string, error) {
+	if len(res.Choices) == 0 {
+		return "", errNoChoices
 	}
+	return res.Choices[0].Message.Content, nil
+}
 
-	parser := func(res jambaResponse) string {
-		return res.Choices[0].Message.Content
+func (s *Bedrock) runBedrockInferenceJambaFamily(ctx context.Context, p *prompt.Prompt, model *Model) (*Response, error) {
+	data := &jambaRequest{}
+	if p.System != "" {
+		data.Messages = append(data.Messages, jambaMessage{Role: prompt.RoleSystem, Content: p.System})
+	}
+	for _, t := range turns(p) {
+		data.Messages = append(data.Messages, jambaMessage{Role: t.Role, Content: t.Content})
 	}
 
-	return runBedrockInference(s, model, data, parser)
+	return runBedrockInference(ctx, s, model, data, jambaCompletion)
 }
 
 type claudeRequest struct {
+	// System is either a string or []claudeSystemBlock when it is cached.
+	System           any             `json:"system,omitempty"`
 	Messages         []claudeMessage `json:"messages"`
+	Tools            []claudeTool    `json:"tools,omitempty"`
 	MaxTokens        int             `json:"max_tokens"`
 	Temperature      float64         `json:"temperature"`
 	TopP             float64         `json:"top_p"`
 	AnthropicVersion string          `json:"anthropic_version"`
 }
 
+type claudeSystemBlock struct {
+	Type         string              `json:"type"`
+	Text         string              `json:"text"`
+	CacheControl *claudeCacheControl `json:"cache_control,omitempty"`
+}
+
+type claudeCacheControl struct {
+	Type string `json:"type"`
+}
+
+type claudeTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
 type claudeResponse struct {
+	// Content holds `text` and `tool_use` blocks.
 	Content []struct {
-		Type string `json:"type"`
-		Text string `json:"text"`
+		Type  string          `json:"type"`
+		Text  string          `json:"text"`
+		Name  string          `json:"name"`
+		Input json.RawMessage `json:"input"`
 	} `json:"content"`
+	Usage struct {
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+	} `json:"usage"`
+}
+
+func (r claudeResponse) cacheTokens() (int, int) {
+	return r.Usage.CacheReadInputTokens, r.Usage.CacheCreationInputTokens
+}
+
+func (r claudeResponse) toolCalls() []prompt.ToolCall {
+	var res []prompt.ToolCall
+	for _, c := range r.Content {
+		if c.Type == "tool_use" {
+			res = append(res, prompt.ToolCall{Name: c.Name, Arguments: string(c.Input)})
+		}
+	}
+
+	return res
 }
 
 type claudeMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role string `json:"role"`
+
+	// Content is either a string or []claudeContent when it has images.
+	Content any `json:"content"`
+}
+
+type claudeContent struct {
+	Type   string             `json:"type"`
+	Text   string             `json:"text,omitempty"`
+	Source *claudeImageSource `json:"source,omitempty"`
+}
+
+type claudeImageSource struct {
+	Type      string `json:"type"`
+	MediaType string `json:"media_type"`
+	Data      string `json:"data"`
+}
+
+func (s *Bedrock) runBedrockInferenceClaudeFamily(ctx context.Context, p *prompt.Prompt, to *Model, onToken func()) (*Response, error) {
+	data := newClaudeRequest(p)
+
+	// Text may precede or be missing along with tool calls.
+	parser := func(in claudeResponse) (string, error) {
+		for _, c := range in.Content {
+			if c.Type == "text" {
+				return c.Text, nil
+			}
+		}
+		return "", nil
+	}
+
+	if onToken != nil {
+		return streamBedrockInference(ctx, s, to, data, claudeChunk, onToken)
+	}
+
+	return runBedrockInference(ctx, s, to, data, parser)
 }
 
-func (s *Bedrock) runBedrockInferenceClaudeFamily(message string, to *Model) (*Response, error) {
+func newClaudeRequest(p *prompt.Prompt) claudeRequest {
 	data := claudeRequest{
-		Messages: []claudeMessage{
-			{Role: "user", Content: message},
-		},
 		MaxTokens:        1024,
 		Temperature:      0.5,
 		TopP:             0.5,
 		AnthropicVersion: "bedrock-2023-05-31",
 	}
-
-	parser := func(in claudeResponse) string {
-		return in.Content[0].Text
+	switch {
+	case p.System != "" && p.CacheSystem:
+		data.System = []claudeSystemBlock{{
+			Type:         "text",
+			Text:         p.System,
+			CacheControl: &claudeCacheControl{Type: "ephemeral"},
+		}}
+	case p.System != "":
+		data.System = p.System
+	}
+	for _, t := range turns(p) {
+		data.Messages = append(data.Messages, claudeMessage{Role: t.Role, Content: t.Content})
+	}
+	// Images precede text of the last user message.
+	if len(p.Images) > 0 {
+		var content []claudeContent
+		for _, img := range p.Images {
+			content = append(content, claudeContent{
+				Type:   "image",
+				Source: &claudeImageSource{Type: "base64", MediaType: img.MediaType, Data: img.Base64()},
+			})
+		}
+		data.Messages[len(data.Messages)-1].Content = append(content, claudeContent{Type: "text", Text: p.Content})
+	}
+	for _, t := range p.Tools {
+		data.Tools = append(data.Tools, claudeTool{Name: t.Name, Description: t.Description, InputSchema: t.Schema()})
 	}
 
-	return runBedrockInference(s, to, data, parser)
+	return data
 }
 
 type commandRRequest struct {
-	Message     string  `json:"message"`
-	Temperature float32 `json:"temperature"`
-	MaxTokens   int     `json:"max_tokens"`
+	Message     string         `json:"message"`
+	ChatHistory []commandRTurn `json:"chat_history,omitempty"`
+	Preamble    string         `json:"preamble,omitempty"`
+	Tools       []commandRTool `json:"tools,omitempty"`
+	Temperature float32        `json:"temperature"`
+	MaxTokens   int            `json:"max_tokens"`
+}
+
+type commandRTool struct {
+	Name                 string                       `json:"name"`
+	Description          string                       `json:"description"`
+	ParameterDefinitions map[string]commandRParameter `json:"parameter_definitions,omitempty"`
+}
+
+type commandRParameter struct {
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type"`
+	Required    bool   `json:"required"`
+}
+
+// commandRTypes maps JSON Schema types onto Command R parameter types.
+var commandRTypes = map[string]string{
+	"string":  "str",
+	"integer": "int",
+	"number":  "float",
+	"boolean": "bool",
+	"array":   "list",
+	"object":  "dict",
+}
+
+type commandRTurn struct {
+	Role    string `json:"role"`
+	Message string `json:"message"`
 }
 
 type commandRResponse struct {
-	Text string `json:"text"`
+	Text      string `json:"text"`
+	ToolCalls []struct {
+		Name       string          `json:"name"`
+		Parameters json.RawMessage `json:"parameters"`
+	} `json:"tool_calls"`
+}
+
+func (r commandRResponse) toolCalls() []prompt.ToolCall {
+	var res []prompt.ToolCall
+	for _, c := range r.ToolCalls {
+		res = append(res, prompt.ToolCall{Name: c.Name, Arguments: string(c.Parameters)})
+	}
+
+	return res
 }
 
-func (s *Bedrock) runBedrockInferenceCommandRFamily(message string, to *Model) (*Response, error) {
+func (s *Bedrock) runBedrockInferenceCommandRFamily(ctx context.Context, p *prompt.Prompt, to *Model) (*Response, error) {
+	data, err := newCommandRRequest(p)
+	if err != nil {
+		return nil, err
+	}
+
+	parser := func(res commandRResponse) (string, error) {
+		return res.Text, nil
+	}
+
+	return runBedrockInference(ctx, s, to, data, parser)
+}
+
+func newCommandRRequest(p *prompt.Prompt) (commandRRequest, error) {
 	data := commandRRequest{
-		Message:     message,
+		Message:     p.Content,
+		Preamble:    p.System,
 		Temperature: 0.1,
 		MaxTokens:   1024,
 	}
+	for _, t := range p.Messages {
+		role := "USER"
+		if t.Role == prompt.RoleAssistant {
+			role = "CHATBOT"
+		}
+		data.ChatHistory = append(data.ChatHistory, commandRTurn{Role: role, Message: t.Content})
+	}
+	for _, t := range p.Tools {
+		tool, err := newCommandRTool(t)
+		if err != nil {
+			return data, err
+		}
+		data.Tools = append(data.Tools, tool)
+	}
+
+	return data, nil
+}
+
+// newCommandRTool maps top level properties of tool schema onto Command R
+// parameter definitions, nested schemas are reduced to their type.
+func newCommandRTool(t prompt.Tool) (commandRTool, error) {
+	var params struct {
+		Properties map[string]struct {
+			Type        any    `json:"type"`
+			Description string `json:"description"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(t.Schema(), &params); err != nil {
+		return commandRTool{}, fmt.Errorf("tool %s parameters: %w", t.Name, err)
+	}
 
-	parser := func(res commandRResponse) string {
-		return res.Text
+	tool := commandRTool{Name: t.Name, Description: t.Description}
+	for name, prop := range params.Properties {
+		if tool.ParameterDefinitions == nil {
+			tool.ParameterDefinitions = map[string]commandRParameter{}
+		}
+
+		typ, _ := prop.Type.(string)
+		if mapped, ok := commandRTypes[typ]; ok {
+			typ = mapped
+		}
+		tool.ParameterDefinitions[name] = commandRParameter{
+			Description: prop.Description,
+			Type:        typ,
+			Required:    slices.Contains(params.Required, name),
+		}
 	}
 
-	return runBedrockInference(s, to, data, parser)
+	return tool, nil
 }
 
 type commandRequest struct {
@@ -392,18 +782,22 @@ type commandResponse struct {
 	Text string `json:"text"`
 }
 
-func (s *Bedrock) runBedrockInferenceCommandFamily(message string, to *Model) (*Response, error) {
+func (s *Bedrock) runBedrockInferenceCommandFamily(ctx context.Context, p *prompt.Prompt, to *Model) (*Response, error) {
+	if err := requireNoSystem(p, to); err != nil {
+		return nil, err
+	}
+
 	data := commandRequest{
-		Prompt:      message,
+		Prompt:      flattenTranscript(p, "User", "Chatbot"),
 		Temperature: 0.1,
 		MaxTokens:   1024,
 	}
 
-	parser := func(res commandResponse) string {
-		return res.Text
+	parser := func(res commandResponse) (string, error) {
+		return res.Text, nil
 	}
 
-	return runBedrockInference(s, to, data, parser)
+	return runBedrockInference(ctx, s, to, data, parser)
 }
 
 type llama3Request struct {
@@ -420,19 +814,23 @@ type llama3Response struct {
 	StopReason           string `json:"stop_reason"`
 }
 
-func (s *Bedrock) runBedrockInferenceLlama3Family(message string, to *Model) (*Response, error) {
+func (s *Bedrock) runBedrockInferenceLlama3Family(ctx context.Context, p *prompt.Prompt, to *Model, onToken func()) (*Response, error) {
 	data := llama3Request{
-		Prompt:      message,
+		Prompt:      llama3Template(p),
 		Temperature: 0.1,
 		TopP:        0.5,
 		MaxGenLen:   1024,
 	}
 
-	parser := func(res llama3Response) string {
-		return res.Generation
+	parser := func(res llama3Response) (string, error) {
+		return res.Generation, nil
+	}
+
+	if onToken != nil {
+		return streamBedrockInference(ctx, s, to, data, llama3Chunk, onToken)
 	}
 
-	return runBedrockInference(s, to, data, parser)
+	return runBedrockInference(ctx, s, to, data, parser)
 }
 
 type mistralRequest struct {
@@ -451,36 +849,40 @@ type mistralResponse struct {
 	Generation string `json:"generation"`
 }
 
-func (s *Bedrock) runBedrockInferenceMistralFamily(message string, to *Model) (*Response, error) {
+func (s *Bedrock) runBedrockInferenceMistralFamily(ctx context.Context, p *prompt.Prompt, to *Model) (*Response, error) {
 	data := mistralRequest{
-		Messages: []mistralMessage{
-			{Role: "user", Content: message},
-		},
 		Temperature: 0.1,
 		TopP:        0.5,
 		MaxTokens:   1024,
 	}
+	if p.System != "" {
+		data.Messages = append(data.Messages, mistralMessage{Role: prompt.RoleSystem, Content: p.System})
+	}
+	for _, t := range turns(p) {
+		data.Messages = append(data.Messages, mistralMessage{Role: t.Role, Content: t.Content})
+	}
 
-	parser := func(res mistralResponse) string {
-		return res.Generation
+	parser := func(res mistralResponse) (string, error) {
+		return res.Generation, nil
 	}
 
-	return runBedrockInference(s, to, data, parser)
+	return runBedrockInference(ctx, s, to, data, parser)
 }
 
 // runBedrockInference is a helper function which wraps common Bedrock API operations.
-// It receives Bedrock client, Model, and two generic types. The first generic
-// is request object to a model, compliant to model's expected data. The second
-// generic is model's output which is provided inside a parser. Parser unpacks
-// model's response type into completion string.
-func runBedrockInference[A, B any](bedrock *Bedrock, withModel *Model, withData A, withParser func(B) string) (*Response, error) {
+// It receives context which cancels the call, Bedrock client, Model, and two generic
+// types. The first generic is request object to a model, compliant to model's
+// expected data. The second generic is model's output which is provided inside
+// a parser. Parser unpacks model's response type into completion string, it
+// fails if response misses the completion.
+func runBedrockInference[A, B any](ctx context.Context, bedrock *Bedrock, withModel *Model, withData A, withParser func(B) (string, error)) (*Response, error) {
 	dataBytes, err := json.Marshal(withData)
 	if err != nil {
 		slog.Debug("failed to marshal model data", "error", err.Error(), "data", withData)
 		return nil, err
 	}
 
-	out, err := bedrock.runtime.InvokeModel(context.TODO(), &bedrockruntime.InvokeModelInput{
+	out, err := bedrock.runtime.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
 		ModelId:     aws.String(withModel.ID),
 		ContentType: aws.String("application/json"),
 		Accept:      aws.String("application/json"),
@@ -488,21 +890,228 @@ func runBedrockInference[A, B any](bedrock *Bedrock, withModel *Model, withData
 	})
 	if err != nil {
 		slog.Debug("failed to invoke model", "error", err.Error(), "model", *withModel, "data", withData)
-		return nil, err
+		return nil, classifyError(ModelProviderBedrock, err)
 	}
 
 	var res B
 	err = json.Unmarshal(out.Body, &res)
 	if err != nil {
 		slog.Debug("failed to unmarshal response", "error", err.Error(), "model", *withModel, "data", withData)
+		return nil, classifyError(ModelProviderBedrock, err)
+	}
+
+	completion, err := withParser(res)
+	if err != nil {
+		slog.Debug("failed to parse response", "error", err.Error(), "model", *withModel, "data", withData)
+		return nil, classifyError(ModelProviderBedrock, err)
+	}
+
+	inputTokens, outputTokens := bedrockTokenCounts(out.ResultMetadata)
+
+	response := &Response{
+		Completion:   completion,
+		InputTokens:  inputTokens,
+		OutputTokens: outputTokens,
+	}
+	if usage, ok := any(res).(bedrockCacheUsage); ok {
+		response.CachedInputTokens, response.CacheWriteTokens = usage.cacheTokens()
+	}
+	if tools, ok := any(res).(bedrockToolUse); ok {
+		response.ToolCalls = tools.toolCalls()
+	}
+
+	return response, nil
+}
+
+// bedrockCacheUsage is implemented by responses of model families which
+// report prompt cache usage in response body, Bedrock headers don't.
+type bedrockCacheUsage interface {
+	// cacheTokens returns input tokens read from cache and written into it.
+	cacheTokens() (int, int)
+}
+
+// bedrockToolUse is implemented by responses of model families which accept
+// tools declared by prompt.
+type bedrockToolUse interface {
+	toolCalls() []prompt.ToolCall
+}
+
+// bedrockChunkParser reads a chunk of a streamed response body. It returns
+// text the chunk carries and sets cache token counts of res if the chunk
+// reports them.
+type bedrockChunkParser func(chunk []byte, res *Response) (string, error)
+
+// bedrockInvocationMetrics are attached by Bedrock to the last chunk of a
+// streamed response of every model family.
+type bedrockInvocationMetrics struct {
+	Metrics *struct {
+		InputTokenCount  int `json:"inputTokenCount"`
+		OutputTokenCount int `json:"outputTokenCount"`
+	} `json:"amazon-bedrock-invocationMetrics"`
+}
+
+// streamBedrockInference is a streamed counterpart of runBedrockInference.
+// It assembles completion from chunks read by withParser and calls onToken
+// for each chunk which carries text.
+func streamBedrockInference[A any](ctx context.Context, bedrock *Bedrock, withModel *Model, withData A, withParser bedrockChunkParser, onToken func()) (*Response, error) {
+	dataBytes, err := json.Marshal(withData)
+	if err != nil {
+		slog.Debug("failed to marshal model data", "error", err.Error(), "data", withData)
 		return nil, err
 	}
 
-	return &Response{
-		Completion: withParser(res),
-	}, nil
+	out, err := bedrock.runtime.InvokeModelWithResponseStream(ctx, &bedrockruntime.InvokeModelWithResponseStreamInput{
+		ModelId:     aws.String(withModel.ID),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
+		Body:        dataBytes,
+	})
+	if err != nil {
+		slog.Debug("failed to invoke model with response stream", "error", err.Error(), "model", *withModel, "data", withData)
+		return nil, classifyError(ModelProviderBedrock, err)
+	}
+
+	stream := out.GetStream()
+	defer stream.Close()
+
+	response := &Response{}
+	var completion strings.Builder
+	for event := range stream.Events() {
+		chunk, ok := event.(*types.ResponseStreamMemberChunk)
+		if !ok {
+			continue
+		}
+
+		text, err := withParser(chunk.Value.Bytes, response)
+		if err != nil {
+			slog.Debug("failed to unmarshal response chunk", "error", err.Error(), "model", *withModel, "data", withData)
+			return nil, classifyError(ModelProviderBedrock, err)
+		}
+		if text != "" {
+			onToken()
+			completion.WriteString(text)
+		}
+
+		var metrics bedrockInvocationMetrics
+		if err := json.Unmarshal(chunk.Value.Bytes, &metrics); err == nil && metrics.Metrics != nil {
+			response.InputTokens = metrics.Metrics.InputTokenCount
+			response.OutputTokens = metrics.Metrics.OutputTokenCount
+		}
+	}
+	if err := stream.Err(); err != nil {
+		slog.Debug("failed to read response stream", "error", err.Error(), "model", *withModel)
+		return nil, classifyError(ModelProviderBedrock, err)
+	}
+
+	response.Completion = completion.String()
+
+	return response, nil
+}
+
+func titanChunk(chunk []byte, _ *Response) (string, error) {
+	var c struct {
+		OutputText string `json:"outputText"`
+	}
+	err := json.Unmarshal(chunk, &c)
+
+	return c.OutputText, err
+}
+
+func llama3Chunk(chunk []byte, _ *Response) (string, error) {
+	var c struct {
+		Generation string `json:"generation"`
+	}
+	err := json.Unmarshal(chunk, &c)
+
+	return c.Generation, err
 }
 
-func (s *Bedrock) Measure(model *Model, prompt *prompt.Prompt) (*Metric, error) {
-	return measure(s, model, prompt)
+// novaChunk reads text of content block deltas, cache usage arrives with
+// metadata chunk.
+func novaChunk(chunk []byte, res *Response) (string, error) {
+	var c struct {
+		ContentBlockDelta *struct {
+			Delta struct {
+				Text string `json:"text"`
+			} `json:"delta"`
+		} `json:"contentBlockDelta"`
+		Metadata *struct {
+			Usage struct {
+				CacheReadInputTokenCount  int `json:"cacheReadInputTokenCount"`
+				CacheWriteInputTokenCount int `json:"cacheWriteInputTokenCount"`
+			} `json:"usage"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(chunk, &c); err != nil {
+		return "", err
+	}
+
+	if c.Metadata != nil {
+		res.CachedInputTokens = c.Metadata.Usage.CacheReadInputTokenCount
+		res.CacheWriteTokens = c.Metadata.Usage.CacheWriteInputTokenCount
+	}
+	if c.ContentBlockDelta == nil {
+		return "", nil
+	}
+
+	return c.ContentBlockDelta.Delta.Text, nil
+}
+
+// claudeChunk reads text deltas of Messages API events, cache usage arrives
+// with message_start event.
+func claudeChunk(chunk []byte, res *Response) (string, error) {
+	var c struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+		Message struct {
+			Usage struct {
+				CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+				CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
+			} `json:"usage"`
+		} `json:"message"`
+	}
+	if err := json.Unmarshal(chunk, &c); err != nil {
+		return "", err
+	}
+
+	switch c.Type {
+	case "message_start":
+		res.CachedInputTokens = c.Message.Usage.CacheReadInputTokens
+		res.CacheWriteTokens = c.Message.Usage.CacheCreationInputTokens
+	case "content_block_delta":
+		if c.Delta.Type == "text_delta" {
+			return c.Delta.Text, nil
+		}
+	}
+
+	return "", nil
+}
+
+// bedrockTokenCounts reads token counts from Bedrock response headers. Bedrock
+// reports them uniformly for every model family, unlike response bodies.
+func bedrockTokenCounts(metadata middleware.Metadata) (int, int) {
+	raw, ok := awsmiddleware.GetRawResponse(metadata).(*smithyhttp.Response)
+	if !ok {
+		return 0, 0
+	}
+
+	input, _ := strconv.Atoi(raw.Header.Get("X-Amzn-Bedrock-Input-Token-Count"))
+	output, _ := strconv.Atoi(raw.Header.Get("X-Amzn-Bedrock-Output-Token-Count"))
+
+	return input, output
+}
+
+// Measure streams completion of families from bedrockStreamFamilies to
+// measure time to first token, the rest are measured by full completion.
+func (s *Bedrock) Measure(ctx context.Context, model *Model, prompt *prompt.Prompt) (*Metric, error) {
+	if model.NoStreaming || !bedrockStreamFamilies[model.Family] || len(prompt.Tools) > 0 {
+		return measure(ctx, s, model, prompt)
+	}
+
+	return measureStream(ctx, model, func(ctx context.Context, onToken func()) (*Response, error) {
+		return s.sendPrompt(ctx, prompt, model, onToken)
+	})
 }
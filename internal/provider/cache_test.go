@@ -0,0 +1,57 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestClaudeRequestCachesSystem(t *testing.T) {
+	plain, err := json.Marshal(newClaudeRequest(conversation))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(plain), `"system":"Be brief."`) {
+		t.Errorf("expected plain system, got %s", plain)
+	}
+
+	cached, err := json.Marshal(newClaudeRequest(conversation.WithCachedPrefix("Prefix")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"system":[{"type":"text","text":"Prefix\nBe brief.","cache_control":{"type":"ephemeral"}}]`
+	if !strings.Contains(string(cached), want) {
+		t.Errorf("expected %s, got %s", want, cached)
+	}
+}
+
+func TestNovaRequestCachesSystem(t *testing.T) {
+	cached, err := json.Marshal(newNovaRequest(conversation.WithCachedPrefix("Prefix")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `"system":[{"text":"Prefix\nBe brief."},{"cachePoint":{"type":"default"}}]`
+	if !strings.Contains(string(cached), want) {
+		t.Errorf("expected %s, got %s", want, cached)
+	}
+}
+
+func TestBedrockCacheUsage(t *testing.T) {
+	var claude claudeResponse
+	body := `{"content":[{"type":"text","text":"Hi"}],"usage":{"cache_read_input_tokens":2100,"cache_creation_input_tokens":0}}`
+	if err := json.Unmarshal([]byte(body), &claude); err != nil {
+		t.Fatal(err)
+	}
+	if read, write := claude.cacheTokens(); read != 2100 || write != 0 {
+		t.Errorf("expected 2100 read and 0 written, got %d and %d", read, write)
+	}
+
+	var nova novaResponse
+	body = `{"output":{"message":{"content":[{"text":"Hi"}]}},"usage":{"cacheReadInputTokenCount":0,"cacheWriteInputTokenCount":2100}}`
+	if err := json.Unmarshal([]byte(body), &nova); err != nil {
+		t.Fatal(err)
+	}
+	if read, write := nova.cacheTokens(); read != 0 || write != 2100 {
+		t.Errorf("expected 0 read and 2100 written, got %d and %d", read, write)
+	}
+}
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/sashabaranov/go-openai"
+)
+
+// userPrompt wraps a single message into a prompt.
+func userPrompt(message string) *prompt.Prompt {
+	return &prompt.Prompt{Type: prompt.PromptTypeUser, Content: message}
+}
+
+// turns returns prior conversation turns followed by the prompt content
+// as the last user turn.
+func turns(p *prompt.Prompt) []prompt.Message {
+	res := make([]prompt.Message, 0, len(p.Messages)+1)
+	res = append(res, p.Messages...)
+	return append(res, prompt.Message{Role: prompt.RoleUser, Content: p.Content})
+}
+
+// systemlessFamilies are model families which accept a single prompt string
+// and have no way to represent a system message.
+var systemlessFamilies = map[ModelFamily]bool{
+	ModelFamilyTitan:    true,
+	ModelFamilyJurassic: true,
+	ModelFamilyCommand:  true,
+}
+
+// requireNoSystem returns ErrSystemPromptUnsupported if prompt has a system
+// message while model family has no way to represent it.
+func requireNoSystem(p *prompt.Prompt, model *Model) error {
+	if p.System != "" {
+		return fmt.Errorf("%w: %s", ErrSystemPromptUnsupported, model.Family)
+	}
+	return nil
+}
+
+// requireNoTools returns ErrToolsUnsupported if prompt declares tools while
+// model family has no way to represent them.
+func requireNoTools(p *prompt.Prompt, model *Model) error {
+	if len(p.Tools) > 0 {
+		return fmt.Errorf("%w: %s", ErrToolsUnsupported, model.Family)
+	}
+	return nil
+}
+
+// requireNoImages returns ErrImagesUnsupported if prompt attaches images
+// while model family has no way to represent them.
+func requireNoImages(p *prompt.Prompt, model *Model) error {
+	if len(p.Images) > 0 {
+		return fmt.Errorf("%w: %s", ErrImagesUnsupported, model.Family)
+	}
+	return nil
+}
+
+// openAIMessages maps prompt onto OpenAI chat completion messages. Images
+// are attached to the last user message as content parts.
+func openAIMessages(p *prompt.Prompt) []openai.ChatCompletionMessage {
+	var res []openai.ChatCompletionMessage
+	if p.System != "" {
+		res = append(res, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: p.System})
+	}
+
+	for _, t := range turns(p) {
+		role := openai.ChatMessageRoleUser
+		if t.Role == prompt.RoleAssistant {
+			role = openai.ChatMessageRoleAssistant
+		}
+		res = append(res, openai.ChatCompletionMessage{Role: role, Content: t.Content})
+	}
+
+	if len(p.Images) > 0 {
+		last := &res[len(res)-1]
+		last.MultiContent = []openai.ChatMessagePart{{Type: openai.ChatMessagePartTypeText, Text: last.Content}}
+		last.Content = ""
+		for _, img := range p.Images {
+			last.MultiContent = append(last.MultiContent, openai.ChatMessagePart{
+				Type:     openai.ChatMessagePartTypeImageURL,
+				ImageURL: &openai.ChatMessageImageURL{URL: img.DataURL()},
+			})
+		}
+	}
+
+	return res
+}
+
+// flattenTranscript renders a conversation into a plain text transcript for
+// families which accept a single prompt string. A single turn prompt is
+// returned as is, multi-turn prompt ends with the assistant label so that
+// model continues the conversation.
+func flattenTranscript(p *prompt.Prompt, userLabel, assistantLabel string) string {
+	if len(p.Messages) == 0 {
+		return p.Content
+	}
+
+	var b strings.Builder
+	for _, t := range turns(p) {
+		label := userLabel
+		if t.Role == prompt.RoleAssistant {
+			label = assistantLabel
+		}
+		fmt.Fprintf(&b, "%s: %s\n", label, t.Content)
+	}
+	b.WriteString(assistantLabel + ":")
+
+	return b.String()
+}
+
+// llama3Template renders a conversation using Llama 3 instruct chat template.
+// A single turn prompt without system message is returned as is.
+func llama3Template(p *prompt.Prompt) string {
+	if len(p.Messages) == 0 && p.System == "" {
+		return p.Content
+	}
+
+	var b strings.Builder
+	b.WriteString("<|begin_of_text|>")
+
+	header := func(role, content string) {
+		fmt.Fprintf(&b, "<|start_header_id|>%s<|end_header_id|>\n\n%s<|eot_id|>", role, content)
+	}
+
+	if p.System != "" {
+		header(prompt.RoleSystem, p.System)
+	}
+	for _, t := range turns(p) {
+		header(t.Role, t.Content)
+	}
+	b.WriteString("<|start_header_id|>assistant<|end_header_id|>\n\n")
+
+	return b.String()
+}
+
+// openAICachedTokens returns input tokens read from prompt cache. OpenAI
+// compatible providers cache long prefixes automatically and report hits
+// in usage details, which some of them omit.
+func openAICachedTokens(usage openai.Usage) int {
+	if usage.PromptTokensDetails == nil {
+		return 0
+	}
+	return usage.PromptTokensDetails.CachedTokens
+}
+
+// openAITools maps tools declared by prompt onto OpenAI function tools.
+func openAITools(p *prompt.Prompt) []openai.Tool {
+	var res []openai.Tool
+	for _, t := range p.Tools {
+		res = append(res, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        t.Name,
+				Description: t.Description,
+				Parameters:  t.Schema(),
+			},
+		})
+	}
+
+	return res
+}
+
+// openAIToolCalls maps function calls of a message onto tool calls.
+func openAIToolCalls(msg openai.ChatCompletionMessage) []prompt.ToolCall {
+	var res []prompt.ToolCall
+	for _, c := range msg.ToolCalls {
+		res = append(res, prompt.ToolCall{Name: c.Function.Name, Arguments: c.Function.Arguments})
+	}
+
+	return res
+}
+
+// openAIResponseFormat constrains completion to response schema of prompt,
+// nil if prompt asks for free text.
+func openAIResponseFormat(p *prompt.Prompt) *openai.ChatCompletionResponseFormat {
+	if len(p.ResponseSchema) == 0 {
+		return nil
+	}
+
+	return &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   "response",
+			Schema: p.ResponseSchema,
+		},
+	}
+}
@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/sashabaranov/go-openai"
+)
+
+var conversation = &prompt.Prompt{
+	System: "Be brief.",
+	Messages: []prompt.Message{
+		{Role: prompt.RoleUser, Content: "Hi"},
+		{Role: prompt.RoleAssistant, Content: "Hello"},
+	},
+	Content: "Bye",
+}
+
+func TestOpenAIMessages(t *testing.T) {
+	msgs := openAIMessages(conversation)
+	if len(msgs) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(msgs))
+	}
+
+	roles := []string{"system", "user", "assistant", "user"}
+	for i, m := range msgs {
+		if m.Role != roles[i] {
+			t.Errorf("message %d: expected role %s, got %s", i, roles[i], m.Role)
+		}
+	}
+
+	if msgs[3].Content != "Bye" {
+		t.Errorf("last message should be prompt content, got %q", msgs[3].Content)
+	}
+}
+
+func TestFlattenTranscript(t *testing.T) {
+	if res := flattenTranscript(userPrompt("Hey"), "User", "Bot"); res != "Hey" {
+		t.Errorf("single turn should be returned as is, got %q", res)
+	}
+
+	want := "User: Hi\nBot: Hello\nUser: Bye\nBot:"
+	if res := flattenTranscript(conversation, "User", "Bot"); res != want {
+		t.Errorf("expected %q, got %q", want, res)
+	}
+}
+
+func TestLlama3Template(t *testing.T) {
+	if res := llama3Template(userPrompt("Hey")); res != "Hey" {
+		t.Errorf("single turn should be returned as is, got %q", res)
+	}
+
+	want := "<|begin_of_text|>" +
+		"<|start_header_id|>system<|end_header_id|>\n\nBe brief.<|eot_id|>" +
+		"<|start_header_id|>user<|end_header_id|>\n\nHi<|eot_id|>" +
+		"<|start_header_id|>assistant<|end_header_id|>\n\nHello<|eot_id|>" +
+		"<|start_header_id|>user<|end_header_id|>\n\nBye<|eot_id|>" +
+		"<|start_header_id|>assistant<|end_header_id|>\n\n"
+	if res := llama3Template(conversation); res != want {
+		t.Errorf("expected %q, got %q", want, res)
+	}
+}
+
+func TestRequireNoSystem(t *testing.T) {
+	titan := &Model{Family: ModelFamilyTitan}
+
+	if err := requireNoSystem(userPrompt("Hey"), titan); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	if err := requireNoSystem(conversation, titan); !errors.Is(err, ErrSystemPromptUnsupported) {
+		t.Errorf("expected ErrSystemPromptUnsupported, got %v", err)
+	}
+}
+
+func TestOpenAIResponseFormat(t *testing.T) {
+	if format := openAIResponseFormat(conversation); format != nil {
+		t.Errorf("expected no format for free text, got %+v", format)
+	}
+
+	p := &prompt.Prompt{Content: "Colors?", ResponseSchema: json.RawMessage(`{"type":"object"}`)}
+	format := openAIResponseFormat(p)
+	if format == nil || format.Type != openai.ChatCompletionResponseFormatTypeJSONSchema || format.JSONSchema.Name == "" {
+		t.Fatalf("expected JSON schema format, got %+v", format)
+	}
+
+	raw, err := json.Marshal(format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"schema":{"type":"object"}`) {
+		t.Errorf("expected schema passed through, got %s", raw)
+	}
+}
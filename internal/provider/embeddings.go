@@ -0,0 +1,234 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/sashabaranov/go-openai"
+)
+
+var (
+	ErrNoEmbeddingInputs = errors.New("no inputs to embed")
+	ErrEmbeddingCount    = errors.New("number of embeddings doesn't match number of inputs")
+	ErrEmbedUnsupported  = errors.New("embeddings are not supported by model family")
+)
+
+// Embedder is implemented by providers which serve embedding models next
+// to LLMs. Embedding models are listed separately, they can't complete
+// prompts.
+type Embedder interface {
+	// Name of the provider.
+	Name() ModelProvider
+
+	// GetEmbeddingModels returns a list of embedding models from memory.
+	GetEmbeddingModels(filter string) []*Model
+
+	// Embed embeds a batch of inputs, one vector per input in order.
+	// Cancelling context aborts the call.
+	Embed(ctx context.Context, inputs []string, model *Model) (*EmbeddingResponse, error)
+
+	// MeasureEmbedding measures a batch of inputs embedded by a particular
+	// model. Cancelling context aborts the call.
+	MeasureEmbedding(ctx context.Context, model *Model, inputs []string) (*EmbeddingMetric, error)
+}
+
+// EmbeddingReserver is implemented by embedders which pace their requests,
+// see Reserver.
+type EmbeddingReserver interface {
+	ReserveEmbedding(ctx context.Context, model *Model, inputs []string) (context.Context, error)
+}
+
+type EmbeddingResponse struct {
+	Vectors [][]float32
+
+	// InputTokens is a token count of all inputs reported by provider,
+	// zero if provider didn't report it.
+	InputTokens int
+}
+
+// EmbeddingMetric wraps embedding response and provides Latency of the
+// whole batch.
+type EmbeddingMetric struct {
+	Model    *Model
+	Latency  time.Duration
+	Response *EmbeddingResponse
+}
+
+func measureEmbedding(ctx context.Context, embedder Embedder, model *Model, inputs []string) (*EmbeddingMetric, error) {
+	start := time.Now()
+	res, err := embedder.Embed(ctx, inputs, model)
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := time.Since(start)
+
+	return &EmbeddingMetric{
+		Model:    model,
+		Latency:  elapsed,
+		Response: res,
+	}, nil
+}
+
+// checkEmbeddings returns response unless provider returned less or more
+// vectors than inputs.
+func checkEmbeddings(res *EmbeddingResponse, inputs []string) (*EmbeddingResponse, error) {
+	if len(res.Vectors) != len(inputs) {
+		return nil, ErrEmbeddingCount
+	}
+
+	return res, nil
+}
+
+// GetEmbeddingModels returns embedding models only which name, ID, family,
+// vendor or provider matches filter.
+func (s *OpenAI) GetEmbeddingModels(filter string) []*Model {
+	return filterModels(s.embeddingModels, filter)
+}
+
+// Embed embeds all inputs in a single call.
+func (s *OpenAI) Embed(ctx context.Context, inputs []string, model *Model) (*EmbeddingResponse, error) {
+	if len(inputs) == 0 {
+		return nil, ErrNoEmbeddingInputs
+	}
+
+	slog.Debug("sending embedding inputs", "count", len(inputs), "to", model)
+
+	res, err := s.client.CreateEmbeddings(ctx, openai.EmbeddingRequest{
+		Input: inputs,
+		Model: openai.EmbeddingModel(model.ID),
+	})
+	if err != nil {
+		return nil, classifyError(ModelProviderOpenAI, err)
+	}
+
+	return checkEmbeddings(openAIEmbeddings(res), inputs)
+}
+
+func (s *OpenAI) MeasureEmbedding(ctx context.Context, model *Model, inputs []string) (*EmbeddingMetric, error) {
+	return measureEmbedding(ctx, s, model, inputs)
+}
+
+// openAIEmbeddings orders vectors by index of their inputs, the API doesn't
+// promise to keep order.
+func openAIEmbeddings(res openai.EmbeddingResponse) *EmbeddingResponse {
+	vectors := make([][]float32, len(res.Data))
+	for _, d := range res.Data {
+		if d.Index >= 0 && d.Index < len(vectors) {
+			vectors[d.Index] = d.Embedding
+		}
+	}
+
+	return &EmbeddingResponse{Vectors: vectors, InputTokens: res.Usage.PromptTokens}
+}
+
+// GetEmbeddingModels returns embedding models only which name, ID, family,
+// vendor or provider matches filter.
+func (s *Bedrock) GetEmbeddingModels(filter string) []*Model {
+	return filterModels(s.embeddingModels, filter)
+}
+
+// Embed embeds inputs with a model of Titan Embed or Cohere Embed family.
+// Cohere Embed takes a whole batch in a single call. Titan Embed takes one
+// input per call, so inputs are sent one after another and latency of
+// a batch is a sum of calls.
+func (s *Bedrock) Embed(ctx context.Context, inputs []string, model *Model) (*EmbeddingResponse, error) {
+	if len(inputs) == 0 {
+		return nil, ErrNoEmbeddingInputs
+	}
+
+	slog.Debug("sending embedding inputs", "count", len(inputs), "to", model)
+
+	switch model.Family {
+	case ModelFamilyTitanEmbed:
+		res := &EmbeddingResponse{}
+		for _, input := range inputs {
+			one, err := runBedrockEmbedding(ctx, s, model, titanEmbedRequest{InputText: input}, func(r titanEmbedResponse) [][]float32 {
+				return [][]float32{r.Embedding}
+			})
+			if err != nil {
+				return nil, err
+			}
+			res.Vectors = append(res.Vectors, one.Vectors...)
+			res.InputTokens += one.InputTokens
+		}
+		return checkEmbeddings(res, inputs)
+	case ModelFamilyCohereEmbed:
+		res, err := runBedrockEmbedding(ctx, s, model, newCohereEmbedRequest(inputs), func(r cohereEmbedResponse) [][]float32 {
+			return r.Embeddings
+		})
+		if err != nil {
+			return nil, err
+		}
+		return checkEmbeddings(res, inputs)
+	default:
+		return nil, ErrEmbedUnsupported
+	}
+}
+
+func (s *Bedrock) MeasureEmbedding(ctx context.Context, model *Model, inputs []string) (*EmbeddingMetric, error) {
+	return measureEmbedding(ctx, s, model, inputs)
+}
+
+type titanEmbedRequest struct {
+	InputText string `json:"inputText"`
+}
+
+type titanEmbedResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+type cohereEmbedRequest struct {
+	Texts     []string `json:"texts"`
+	InputType string   `json:"input_type"`
+}
+
+type cohereEmbedResponse struct {
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+// newCohereEmbedRequest embeds inputs as documents, which is what they
+// are embedded as for search most often.
+func newCohereEmbedRequest(inputs []string) cohereEmbedRequest {
+	return cohereEmbedRequest{Texts: inputs, InputType: "search_document"}
+}
+
+// runBedrockEmbedding is a counterpart of runBedrockInference for embedding
+// models. Parser unpacks model's response type into vectors.
+func runBedrockEmbedding[A, B any](ctx context.Context, bedrock *Bedrock, withModel *Model, withData A, withParser func(B) [][]float32) (*EmbeddingResponse, error) {
+	dataBytes, err := json.Marshal(withData)
+	if err != nil {
+		slog.Debug("failed to marshal model data", "error", err.Error(), "model", *withModel)
+		return nil, err
+	}
+
+	out, err := bedrock.runtime.InvokeModel(ctx, &bedrockruntime.InvokeModelInput{
+		ModelId:     aws.String(withModel.ID),
+		ContentType: aws.String("application/json"),
+		Accept:      aws.String("application/json"),
+		Body:        dataBytes,
+	})
+	if err != nil {
+		slog.Debug("failed to invoke model", "error", err.Error(), "model", *withModel)
+		return nil, classifyError(ModelProviderBedrock, err)
+	}
+
+	var res B
+	err = json.Unmarshal(out.Body, &res)
+	if err != nil {
+		slog.Debug("failed to unmarshal response", "error", err.Error(), "model", *withModel)
+		return nil, classifyError(ModelProviderBedrock, err)
+	}
+
+	inputTokens, _ := bedrockTokenCounts(out.ResultMetadata)
+
+	return &EmbeddingResponse{
+		Vectors:     withParser(res),
+		InputTokens: inputTokens,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestOpenAIEmbeddingsOrder(t *testing.T) {
+	res := openAIEmbeddings(openai.EmbeddingResponse{
+		Data: []openai.Embedding{
+			{Index: 1, Embedding: []float32{2}},
+			{Index: 0, Embedding: []float32{1}},
+		},
+		Usage: openai.Usage{PromptTokens: 7},
+	})
+
+	if len(res.Vectors) != 2 || res.Vectors[0][0] != 1 || res.Vectors[1][0] != 2 {
+		t.Errorf("expected vectors in order of inputs, got %v", res.Vectors)
+	}
+	if res.InputTokens != 7 {
+		t.Errorf("expected 7 input tokens, got %d", res.InputTokens)
+	}
+}
+
+func TestCohereEmbedRequest(t *testing.T) {
+	body, err := json.Marshal(newCohereEmbedRequest([]string{"a", "b"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"texts":["a","b"],"input_type":"search_document"}`
+	if string(body) != want {
+		t.Errorf("expected %s, got %s", want, body)
+	}
+
+	var res cohereEmbedResponse
+	if err := json.Unmarshal([]byte(`{"id":"1","embeddings":[[0.1,0.2],[0.3,0.4]],"texts":["a","b"]}`), &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Embeddings) != 2 || len(res.Embeddings[1]) != 2 {
+		t.Errorf("unexpected embeddings: %v", res.Embeddings)
+	}
+}
+
+func TestCheckEmbeddings(t *testing.T) {
+	res := &EmbeddingResponse{Vectors: [][]float32{{1}}}
+
+	if _, err := checkEmbeddings(res, []string{"a"}); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if _, err := checkEmbeddings(res, []string{"a", "b"}); !errors.Is(err, ErrEmbeddingCount) {
+		t.Errorf("expected ErrEmbeddingCount, got %v", err)
+	}
+}
+
+func TestEmbeddingModelsAreListedSeparately(t *testing.T) {
+	p, err := NewOpenAI("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, m := range p.GetEmbeddingModels("") {
+		if m.Dimensions == 0 {
+			t.Errorf("expected dimensions of %s", m.ID)
+		}
+		if len(p.GetLLMModels(m.ID)) != 0 {
+			t.Errorf("embedding model %s is listed as LLM", m.ID)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	"github.com/sashabaranov/go-openai"
+)
+
+// Classes of failed calls, common to all providers. Calls fail with *Error
+// which matches its class with errors.Is.
+var (
+	ErrAuth              = errors.New("authentication failed")
+	ErrAccessDenied      = errors.New("access denied")
+	ErrThrottled         = errors.New("throttled")
+	ErrTimeout           = errors.New("timed out")
+	ErrModelNotFound     = errors.New("model not found")
+	ErrInvalidRequest    = errors.New("invalid request")
+	ErrServer            = errors.New("server error")
+	ErrMalformedResponse = errors.New("malformed response")
+	ErrUnknown           = errors.New("unknown error")
+
+	errNoChoices = errors.New("response has no choices")
+	errNoResults = errors.New("response has no results")
+)
+
+// Error is a failed call to a provider.
+type Error struct {
+	// Class of the error, such as ErrThrottled.
+	Class error
+
+	// Provider which call failed.
+	Provider ModelProvider
+
+	// StatusCode is HTTP status code of a response, zero if there was
+	// no response.
+	StatusCode int
+
+	// Err is the original error returned by SDK or by parsing a response.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Class, e.Err)
+}
+
+// Unwrap allows to match both class and the original error.
+func (e *Error) Unwrap() []error {
+	return []error{e.Class, e.Err}
+}
+
+// ClassOf returns class of an error returned by a provider, nil if the
+// error is not a failed call, e.g. it was cancelled.
+func ClassOf(err error) error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Class
+	}
+	return nil
+}
+
+// bedrockErrorCodes maps Bedrock error codes onto classes. Bedrock denies
+// access to models which are not enabled in the account.
+var bedrockErrorCodes = map[string]error{
+	"UnrecognizedClientException":         ErrAuth,
+	"InvalidSignatureException":           ErrAuth,
+	"IncompleteSignatureException":        ErrAuth,
+	"MissingAuthenticationTokenException": ErrAuth,
+	"ExpiredTokenException":               ErrAuth,
+	"AccessDeniedException":               ErrAccessDenied,
+	"ThrottlingException":                 ErrThrottled,
+	"ServiceQuotaExceededException":       ErrThrottled,
+	"ModelTimeoutException":               ErrTimeout,
+	"ResourceNotFoundException":           ErrModelNotFound,
+	"ValidationException":                 ErrInvalidRequest,
+	"InternalServerException":             ErrServer,
+	"ServiceUnavailableException":         ErrServer,
+	"ModelNotReadyException":              ErrServer,
+	"ModelErrorException":                 ErrServer,
+}
+
+// openAIErrorCodes maps OpenAI compatible error codes onto classes where
+// status code is ambiguous. Exhausted quota is reported as 429, yet unlike
+// throttling it doesn't go away by waiting.
+var openAIErrorCodes = map[string]error{
+	"invalid_api_key":    ErrAuth,
+	"insufficient_quota": ErrAccessDenied,
+	"model_not_found":    ErrModelNotFound,
+}
+
+// classifyError wraps an error of a call to a provider into *Error.
+// Cancelled calls and errors which are already classified are returned
+// as is.
+func classifyError(name ModelProvider, err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	class, status := classOf(err)
+	return &Error{Class: class, Provider: name, StatusCode: status, Err: err}
+}
+
+// classOf detects class and HTTP status code of an error.
+func classOf(err error) (error, int) {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrTimeout, 0
+	}
+
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		if code, ok := apiErr.Code.(string); ok {
+			if class, ok := openAIErrorCodes[code]; ok {
+				return class, apiErr.HTTPStatusCode
+			}
+		}
+		return classOfStatus(apiErr.HTTPStatusCode), apiErr.HTTPStatusCode
+	}
+
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return classOfStatus(reqErr.HTTPStatusCode), reqErr.HTTPStatusCode
+	}
+
+	var smithyErr smithy.APIError
+	if errors.As(err, &smithyErr) {
+		var status int
+		var resErr *awshttp.ResponseError
+		if errors.As(err, &resErr) {
+			status = resErr.HTTPStatusCode()
+		}
+
+		if class, ok := bedrockErrorCodes[smithyErr.ErrorCode()]; ok {
+			return class, status
+		}
+		return classOfStatus(status), status
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, errNoChoices) || errors.Is(err, errNoResults) {
+		return ErrMalformedResponse, 0
+	}
+
+	return ErrUnknown, 0
+}
+
+// classOfStatus detects class of an error by HTTP status code.
+func classOfStatus(status int) error {
+	switch {
+	case status == http.StatusUnauthorized:
+		return ErrAuth
+	case status == http.StatusForbidden:
+		return ErrAccessDenied
+	case status == http.StatusNotFound:
+		return ErrModelNotFound
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout:
+		return ErrTimeout
+	case status == http.StatusTooManyRequests:
+		return ErrThrottled
+	case status >= 500:
+		return ErrServer
+	case status >= 400:
+		return ErrInvalidRequest
+	default:
+		return ErrUnknown
+	}
+}
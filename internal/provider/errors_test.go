@@ -0,0 +1,119 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestClassifyError(t *testing.T) {
+	bedrockError := func(code string, status int) error {
+		return &smithy.OperationError{
+			ServiceID:     "Bedrock Runtime",
+			OperationName: "InvokeModel",
+			Err: &awshttp.ResponseError{
+				ResponseError: &smithyhttp.ResponseError{
+					Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+					Err:      &smithy.GenericAPIError{Code: code, Message: "message"},
+				},
+			},
+		}
+	}
+
+	var syntaxErr error
+	if err := json.Unmarshal([]byte("{"), &struct{}{}); err != nil {
+		syntaxErr = err
+	}
+
+	tests := []struct {
+		name   string
+		err    error
+		class  error
+		status int
+	}{
+		{"openai throttled", &openai.APIError{HTTPStatusCode: 429, Code: "rate_limit_exceeded"}, ErrThrottled, 429},
+		{"openai quota", &openai.APIError{HTTPStatusCode: 429, Code: "insufficient_quota"}, ErrAccessDenied, 429},
+		{"openai auth", &openai.APIError{HTTPStatusCode: 401}, ErrAuth, 401},
+		{"openai model", &openai.APIError{HTTPStatusCode: 404, Code: "model_not_found"}, ErrModelNotFound, 404},
+		{"openai invalid", &openai.APIError{HTTPStatusCode: 400}, ErrInvalidRequest, 400},
+		{"request server", &openai.RequestError{HTTPStatusCode: 503, Err: errors.New("unavailable")}, ErrServer, 503},
+		{"bedrock access", bedrockError("AccessDeniedException", 403), ErrAccessDenied, 403},
+		{"bedrock throttled", bedrockError("ThrottlingException", 429), ErrThrottled, 429},
+		{"bedrock timeout", bedrockError("ModelTimeoutException", 408), ErrTimeout, 408},
+		{"bedrock unknown code", bedrockError("SomethingException", 502), ErrServer, 502},
+		{"deadline", fmt.Errorf("post: %w", context.DeadlineExceeded), ErrTimeout, 0},
+		{"malformed json", syntaxErr, ErrMalformedResponse, 0},
+		{"no choices", errNoChoices, ErrMalformedResponse, 0},
+		{"no results", errNoResults, ErrMalformedResponse, 0},
+		{"unknown", errors.New("boom"), ErrUnknown, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyError(ModelProviderGroq, tt.err)
+
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("expected *Error, got %T", err)
+			}
+			if e.Class != tt.class || e.StatusCode != tt.status {
+				t.Errorf("expected %v %d, got %v %d", tt.class, tt.status, e.Class, e.StatusCode)
+			}
+			if !errors.Is(err, tt.class) || !errors.Is(err, tt.err) {
+				t.Error("expected error to match both class and original error")
+			}
+			if ClassOf(err) != tt.class {
+				t.Errorf("expected ClassOf to return %v, got %v", tt.class, ClassOf(err))
+			}
+		})
+	}
+}
+
+func TestBedrockEmptyResponse(t *testing.T) {
+	parsers := map[string]func(body string) error{
+		"titan":    parseWith(titanCompletion),
+		"jurassic": parseWith(jurassicCompletion),
+		"jamba":    parseWith(jambaCompletion),
+	}
+
+	for name, parse := range parsers {
+		for _, body := range []string{`{}`, `{"results":[],"completions":[],"choices":[]}`} {
+			err := classifyError(ModelProviderBedrock, parse(body))
+			if ClassOf(err) != ErrMalformedResponse {
+				t.Errorf("%s: expected ErrMalformedResponse for %s, got %v", name, body, err)
+			}
+		}
+	}
+}
+
+// parseWith unmarshals a body into a response of a parser and parses it.
+func parseWith[B any](parser func(B) (string, error)) func(body string) error {
+	return func(body string) error {
+		var res B
+		if err := json.Unmarshal([]byte(body), &res); err != nil {
+			return err
+		}
+		_, err := parser(res)
+		return err
+	}
+}
+
+func TestClassifyErrorKeepsCancellation(t *testing.T) {
+	err := fmt.Errorf("post: %w", context.Canceled)
+	if got := classifyError(ModelProviderOpenAI, err); got != err || ClassOf(got) != nil {
+		t.Errorf("expected cancellation to be kept as is, got %v", got)
+	}
+
+	classified := classifyError(ModelProviderOpenAI, errors.New("boom"))
+	if got := classifyError(ModelProviderOpenAI, classified); got != classified {
+		t.Errorf("expected classified error to be kept as is, got %v", got)
+	}
+}
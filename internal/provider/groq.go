@@ -34,7 +34,7 @@ func NewGroq(apiKey string) (*Groq, error) {
 		{ID: "llama3-70b-8192", Name: "Llama3 70b 8192", Provider: ModelProviderGroq, Vendor: ModelVendorMeta, Family: ModelFamilyLlama3},
 		{ID: "llama3-8b-8192", Name: "Llama3 8b 8192", Provider: ModelProviderGroq, Vendor: ModelVendorMeta, Family: ModelFamilyLlama3},
 		{ID: "mixtral-8x7b-32768", Name: "Mixtral 8x7b 32768", Provider: ModelProviderGroq, Vendor: ModelVendorMistralAI, Family: ModelFamilyMixtral},
-		{ID: "deepseek-r1-distill-llama-70b", Name: "DeepSeek R1 Distill Llama 70B", Provider: ModelProviderGroq, Vendor: ModelVendorDeepSeek, Family: ModelFamilyR1},
+		{ID: "deepseek-r1-distill-llama-70b", Name: "DeepSeek R1 Distill Llama 70B", Provider: ModelProviderGroq, Vendor: ModelVendorDeepSeek, Family: ModelFamilyR1, Reasoning: true},
 		{ID: "llama-3.2-1b-preview", Name: "Llama 3.2 1b Preview", Provider: ModelProviderGroq, Vendor: ModelVendorMeta, Family: ModelFamilyLlama3},
 		{ID: "llama-3.2-3b-preview", Name: "Llama 3.2 3b Preview", Provider: ModelProviderGroq, Vendor: ModelVendorMeta, Family: ModelFamilyLlama3},
 	}
@@ -71,37 +71,79 @@ func (s *Groq) GetLLMModels(filter string) []*Model {
 }
 
 func (s *Groq) Send(message string, model *Model) (*Response, error) {
+	return s.SendPrompt(context.Background(), userPrompt(message), model)
+}
+
+func (s *Groq) SendPrompt(ctx context.Context, p *prompt.Prompt, model *Model) (*Response, error) {
 	switch model.Vendor {
 	case ModelVendorGoogle:
-		return s.runGroqInference(model, message)
+		return s.runGroqInference(ctx, model, p)
 	case ModelVendorMeta:
-		return s.runGroqInference(model, message)
+		return s.runGroqInference(ctx, model, p)
 	case ModelVendorMistralAI:
-		return s.runGroqInference(model, message)
+		return s.runGroqInference(ctx, model, p)
 	case ModelVendorDeepSeek:
-		return s.runGroqInference(model, message)
+		return s.runGroqInference(ctx, model, p)
 
 	default:
 		return nil, fmt.Errorf("unsupported vendor: %s", model.Vendor)
 	}
 }
 
-func (s *Groq) runGroqInference(model *Model, message string) (*Response, error) {
-	res, err := s.client.CreateChatCompletion(
-		context.Background(),
-		openai.ChatCompletionRequest{
-			Model: model.ID,
-			Messages: []openai.ChatCompletionMessage{
-				{Role: openai.ChatMessageRoleUser, Content: message},
-			},
-		})
+func (s *Groq) runGroqInference(ctx context.Context, model *Model, p *prompt.Prompt) (*Response, error) {
+	res, err := s.client.CreateChatCompletion(ctx, groqRequest(p, model))
 	if err != nil {
-		return nil, err
+		return nil, classifyError(ModelProviderGroq, err)
+	}
+	if len(res.Choices) == 0 {
+		return nil, classifyError(ModelProviderGroq, errNoChoices)
+	}
+
+	response := &Response{
+		Completion:        res.Choices[0].Message.Content,
+		InputTokens:       res.Usage.PromptTokens,
+		OutputTokens:      res.Usage.CompletionTokens,
+		CachedInputTokens: openAICachedTokens(res.Usage),
+		ToolCalls:         openAIToolCalls(res.Choices[0].Message),
+		ReasoningTokens:   openAIReasoningTokens(res.Usage),
+	}
+	if model.Reasoning {
+		stripThinking(response)
+	}
+
+	return response, nil
+}
+
+func groqRequest(p *prompt.Prompt, model *Model) openai.ChatCompletionRequest {
+	// Groq JSON mode doesn't take a schema, it is described in content.
+	var format *openai.ChatCompletionResponseFormat
+	if len(p.ResponseSchema) > 0 {
+		format = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
 	}
 
-	return &Response{Completion: res.Choices[0].Message.Content}, nil
+	req := newOpenAIRequest(p.WithStructuredInstruction(), model)
+	req.ResponseFormat = format
+
+	return req
 }
 
-func (s *Groq) Measure(model *Model, prompt *prompt.Prompt) (*Metric, error) {
-	return measure(s, model, prompt)
+// Measure streams completion to measure time to first token. Groq doesn't
+// stream JSON mode, prompts with a response schema are measured by their
+// full completion.
+func (s *Groq) Measure(ctx context.Context, model *Model, prompt *prompt.Prompt) (*Metric, error) {
+	if model.NoStreaming || len(prompt.ResponseSchema) > 0 {
+		return measure(ctx, s, model, prompt)
+	}
+
+	return measureStream(ctx, model, func(ctx context.Context, onToken func()) (*Response, error) {
+		res, err := streamOpenAI(ctx, s.client, ModelProviderGroq, groqRequest(prompt, model), onToken)
+		if err != nil {
+			return nil, err
+		}
+		if model.Reasoning {
+			stripThinking(res)
+		}
+
+		return res, nil
+	})
 }
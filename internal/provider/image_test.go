@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/sashabaranov/go-openai"
+)
+
+var imagePrompt = &prompt.Prompt{
+	Content: "What is it?",
+	Images:  []prompt.Image{{MediaType: "image/png", Data: []byte("png")}},
+}
+
+func TestOpenAIMessagesWithImages(t *testing.T) {
+	msgs := openAIMessages(imagePrompt)
+
+	parts := msgs[len(msgs)-1].MultiContent
+	if len(parts) != 2 || parts[0].Text != "What is it?" || parts[1].Type != openai.ChatMessagePartTypeImageURL {
+		t.Fatalf("unexpected parts: %+v", parts)
+	}
+	if parts[1].ImageURL.URL != "data:image/png;base64,cG5n" {
+		t.Errorf("unexpected image URL %q", parts[1].ImageURL.URL)
+	}
+}
+
+func TestClaudeRequestWithImages(t *testing.T) {
+	req, err := json.Marshal(newClaudeRequest(imagePrompt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `"content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"cG5n"}},{"type":"text","text":"What is it?"}]`
+	if !strings.Contains(string(req), want) {
+		t.Errorf("expected %s, got %s", want, req)
+	}
+}
+
+func TestNovaRequestWithImages(t *testing.T) {
+	req, err := json.Marshal(newNovaRequest(imagePrompt))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `"content":[{"image":{"format":"png","source":{"bytes":"cG5n"}}},{"text":"What is it?"}]`
+	if !strings.Contains(string(req), want) {
+		t.Errorf("expected %s, got %s", want, req)
+	}
+}
+
+func TestBedrockRejectsImages(t *testing.T) {
+	mistral := &Model{Vendor: ModelVendorMistralAI, Family: ModelFamilyMistral}
+
+	_, err := (&Bedrock{}).SendPrompt(context.Background(), imagePrompt, mistral)
+	if !errors.Is(err, ErrImagesUnsupported) {
+		t.Errorf("expected ErrImagesUnsupported, got %v", err)
+	}
+}
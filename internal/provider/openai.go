@@ -2,6 +2,7 @@ package provider
 
 import (
 	"context"
+	"fmt"
 	"github.com/pvlbzn/latai/internal/prompt"
 	"github.com/sashabaranov/go-openai"
 	"log/slog"
@@ -10,8 +11,9 @@ import (
 )
 
 type OpenAI struct {
-	client *openai.Client
-	models []Model
+	client          *openai.Client
+	models          []Model
+	embeddingModels []Model
 }
 
 func NewOpenAI(apiKey string) (*OpenAI, error) {
@@ -23,34 +25,41 @@ func NewOpenAI(apiKey string) (*OpenAI, error) {
 	}
 
 	c := openai.NewClient(apiKey)
+	// Reasoning models which accept effort are asked for low effort, with
+	// default effort a single sample may take minutes.
 	models := []Model{
 		{ID: "gpt-4-1106-preview", Name: "GPT 4 1106 Preview", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
 		{ID: "gpt-3.5-turbo", Name: "GPT 3.5 Turbo", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
 		{ID: "gpt-3.5-turbo-0125", Name: "GPT 3.5 Turbo 0125", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "o1-mini", Name: "O1 Mini", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "o1-mini-2024-09-12", Name: "O1 Mini 2024 0`9 12", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "o1-2024-12-17", Name: "O1 2024 12 17", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
+		{ID: "o1-mini", Name: "O1 Mini", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, NoSystemRole: true, Reasoning: true},
+		{ID: "o1-mini-2024-09-12", Name: "O1 Mini 2024 0`9 12", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, NoSystemRole: true, Reasoning: true},
+		{ID: "o1-2024-12-17", Name: "O1 2024 12 17", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true, NoStreaming: true, Reasoning: true, ReasoningEffort: "low"},
 		{ID: "gpt-3.5-turbo-16k", Name: "GPT 3.5 Turbo 16k", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "o1", Name: "O1", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "o1-preview-2024-09-12", Name: "O1 Preview 2024 09 12", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "o1-preview", Name: "O1 Preview", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
+		{ID: "o1", Name: "O1", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true, NoStreaming: true, Reasoning: true, ReasoningEffort: "low"},
+		{ID: "o1-preview-2024-09-12", Name: "O1 Preview 2024 09 12", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, NoSystemRole: true, Reasoning: true},
+		{ID: "o1-preview", Name: "O1 Preview", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, NoSystemRole: true, Reasoning: true},
 		{ID: "gpt-4", Name: "GPT 4", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
 		{ID: "gpt-4-0613", Name: "GPT 4 0613", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "chatgpt-4o-latest", Name: "ChatGPT 4o Latest", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4o-2024-08-06", Name: "GPT 4o 2024 08 06", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4o", Name: "GPT 4o", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
+		{ID: "chatgpt-4o-latest", Name: "ChatGPT 4o Latest", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
+		{ID: "gpt-4o-2024-08-06", Name: "GPT 4o 2024 08 06", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
+		{ID: "gpt-4o", Name: "GPT 4o", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
 		{ID: "gpt-3.5-turbo-1106", Name: "GPT 3.5 Turbo 1106", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4-turbo-2024-04-09", Name: "GPT 4 Turbo 2024 04 09", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4-turbo", Name: "GPT 4 Turbo", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
+		{ID: "gpt-4-turbo-2024-04-09", Name: "GPT 4 Turbo 2024 04 09", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
+		{ID: "gpt-4-turbo", Name: "GPT 4 Turbo", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
 		{ID: "gpt-4-turbo-preview", Name: "GPT 4 Turbo Preview", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4o-2024-05-13", Name: "GPT 4o 2024 05 13", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4o-2024-11-20", Name: "GPT 4o 2024 11 20", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4o-mini-2024-07-18", Name: "GPT 4o Mini 2024 07 18", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
-		{ID: "gpt-4o-mini", Name: "GPT 4o Mini", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
+		{ID: "gpt-4o-2024-05-13", Name: "GPT 4o 2024 05 13", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
+		{ID: "gpt-4o-2024-11-20", Name: "GPT 4o 2024 11 20", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
+		{ID: "gpt-4o-mini-2024-07-18", Name: "GPT 4o Mini 2024 07 18", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
+		{ID: "gpt-4o-mini", Name: "GPT 4o Mini", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT, Vision: true},
 		{ID: "gpt-4-0125-preview", Name: "GPT 4 0125 Preview", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
 	}
 
-	return &OpenAI{client: c, models: models}, nil
+	embeddingModels := []Model{
+		{ID: "text-embedding-3-small", Name: "Text Embedding 3 Small", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyTextEmbedding, Dimensions: 1536},
+		{ID: "text-embedding-3-large", Name: "Text Embedding 3 Large", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyTextEmbedding, Dimensions: 3072},
+	}
+
+	return &OpenAI{client: c, models: models, embeddingModels: embeddingModels}, nil
 }
 
 // id2Name converts ID to name format. E.g. transforms `o1-preview-2024-09-12`
@@ -85,31 +94,69 @@ func (s *OpenAI) VerifyAccess() bool {
 }
 
 // GetLLMModels returns LLM models only. Filter is applied to search
-// models by their name, e.g. "4o" filter will return 4o family models.
-// Empty filter returns full list of available LLM models.
+// models by their name, ID, family, vendor or provider, e.g. "4o" filter
+// will return 4o family models. Empty filter returns full list of
+// available LLM models.
 func (s *OpenAI) GetLLMModels(filter string) []*Model {
 	return filterModels(s.models, filter)
 }
 
 func (s *OpenAI) Send(message string, to *Model) (*Response, error) {
-	slog.Debug("sending message", "message", message, "to", to)
+	return s.SendPrompt(context.Background(), userPrompt(message), to)
+}
 
-	res, err := s.client.CreateChatCompletion(
-		context.TODO(),
-		openai.ChatCompletionRequest{
-			Model: to.ID,
-			Messages: []openai.ChatCompletionMessage{
-				{Role: openai.ChatMessageRoleUser, Content: message},
-			},
-		})
+func (s *OpenAI) SendPrompt(ctx context.Context, p *prompt.Prompt, to *Model) (*Response, error) {
+	slog.Debug("sending prompt", "prompt", p, "to", to)
 
+	req, err := s.request(p, to)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Response{Completion: res.Choices[0].Message.Content}, nil
+	res, err := s.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, classifyError(ModelProviderOpenAI, err)
+	}
+	if len(res.Choices) == 0 {
+		return nil, classifyError(ModelProviderOpenAI, errNoChoices)
+	}
+
+	return &Response{
+		Completion:        res.Choices[0].Message.Content,
+		InputTokens:       res.Usage.PromptTokens,
+		OutputTokens:      res.Usage.CompletionTokens,
+		CachedInputTokens: openAICachedTokens(res.Usage),
+		ToolCalls:         openAIToolCalls(res.Choices[0].Message),
+		ReasoningTokens:   openAIReasoningTokens(res.Usage),
+	}, nil
 }
 
-func (s *OpenAI) Measure(model *Model, prompt *prompt.Prompt) (*Metric, error) {
-	return measure(s, model, prompt)
+func (s *OpenAI) request(p *prompt.Prompt, to *Model) (openai.ChatCompletionRequest, error) {
+	if p.System != "" && !to.SupportsSystemRole() {
+		return openai.ChatCompletionRequest{}, fmt.Errorf("%w: %s", ErrSystemPromptUnsupported, to.ID)
+	}
+
+	req := newOpenAIRequest(p, to)
+	req.ResponseFormat = openAIResponseFormat(p)
+
+	return req, nil
+}
+
+// Measure streams completion to measure time to first token, models which
+// don't stream are measured by their full completion.
+func (s *OpenAI) Measure(ctx context.Context, model *Model, prompt *prompt.Prompt) (*Metric, error) {
+	if model.NoStreaming {
+		return measure(ctx, s, model, prompt)
+	}
+
+	slog.Debug("streaming prompt", "prompt", prompt, "to", model)
+
+	req, err := s.request(prompt, model)
+	if err != nil {
+		return nil, err
+	}
+
+	return measureStream(ctx, model, func(ctx context.Context, onToken func()) (*Response, error) {
+		return streamOpenAI(ctx, s.client, ModelProviderOpenAI, req, onToken)
+	})
 }
@@ -1,6 +1,7 @@
 package provider
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"time"
@@ -11,6 +12,10 @@ import (
 var (
 	ErrAPIKeyNotFound = errors.New("API key not found")
 	ErrAPIKeyInvalid  = errors.New("API key is invalid")
+
+	ErrSystemPromptUnsupported = errors.New("system prompt is not supported by model family")
+	ErrToolsUnsupported        = errors.New("tools are not supported by model family")
+	ErrImagesUnsupported       = errors.New("images are not supported by model family")
 )
 
 // Provider is a core interface for each provider implementation
@@ -24,25 +29,65 @@ type Provider interface {
 	GetLLMModels(filter string) []*Model
 
 	// Measure measures a particular model and returns Metric back.
-	Measure(model *Model, prompt *prompt.Prompt) (*Metric, error)
+	// Cancelling context aborts the call.
+	Measure(ctx context.Context, model *Model, prompt *prompt.Prompt) (*Metric, error)
 
-	// Send a message to LLM. Can be used stand alone, and is used
-	// by Measure internally to make calls to gather metrics.
+	// Send a message to LLM. Can be used stand alone. It is a shortcut
+	// for SendPrompt with a single user message.
 	Send(message string, to *Model) (*Response, error)
 
+	// SendPrompt sends a full prompt including system message and prior
+	// conversation turns to LLM. Is used by Measure internally to make calls
+	// to gather metrics. Cancelling context aborts the HTTP call.
+	SendPrompt(ctx context.Context, p *prompt.Prompt, to *Model) (*Response, error)
+
 	// VerifyAccess validates whether user provider API key,
 	// and whether this API key is functioning.
 	VerifyAccess() bool
 }
 
+// Reserver is implemented by providers which pace their requests. Reserve
+// blocks until a request of a prompt may be sent and returns context which
+// carries the reservation, Measure called with it doesn't wait again. This
+// lets callers wait before a deadline of the call starts.
+type Reserver interface {
+	Reserve(ctx context.Context, model *Model, p *prompt.Prompt) (context.Context, error)
+}
+
 type Response struct {
 	Completion string `json:"completion"`
+
+	// InputTokens and OutputTokens are token counts reported by
+	// provider, zero if provider didn't report them.
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+
+	// CachedInputTokens are input tokens read from prompt cache and
+	// CacheWriteTokens are input tokens written into it, zero if provider
+	// didn't report them.
+	CachedInputTokens int `json:"cached_input_tokens"`
+	CacheWriteTokens  int `json:"cache_write_tokens"`
+
+	// ToolCalls are calls of tools declared by prompt, a model may return
+	// them instead of a completion.
+	ToolCalls []prompt.ToolCall `json:"tool_calls,omitempty"`
+
+	// ReasoningTokens are output tokens spent on hidden reasoning, a part
+	// of OutputTokens, zero if model doesn't reason or provider didn't
+	// report them.
+	ReasoningTokens int `json:"reasoning_tokens"`
 }
 
 // Metric wraps model data and provides Latency extra field.
 type Metric struct {
-	Model    *Model
-	Latency  time.Duration
+	Model   *Model
+	Latency time.Duration
+
+	// FirstToken is latency until the first token of a streamed completion
+	// arrived, zero if completion wasn't streamed. Latency of a streamed
+	// completion lasts until its last token.
+	FirstToken time.Duration
+
 	Response *Response
 }
 
@@ -64,6 +109,29 @@ type Model struct {
 	// Model vendor, that is name of a company which built
 	// the model itself such as Anthropic, Google, Amazon, etc.
 	Vendor ModelVendor
+
+	// Vision reports whether model accepts images attached to prompts.
+	Vision bool
+
+	// Dimensions is a length of vectors returned by an embedding model,
+	// zero for LLMs.
+	Dimensions int
+
+	// NoSystemRole is set for models which reject system messages, see
+	// SupportsSystemRole.
+	NoSystemRole bool
+
+	// NoStreaming is set for models which don't stream completions, time
+	// to first token of such models is unknown.
+	NoStreaming bool
+
+	// Reasoning reports whether model reasons before answering. Hidden
+	// reasoning takes time and output tokens not seen in completion.
+	Reasoning bool
+
+	// ReasoningEffort is sent to a reasoning model which accepts it, such
+	// as `low`, empty otherwise.
+	ReasoningEffort string
 }
 
 type ModelFamily string
@@ -85,6 +153,10 @@ const (
 	ModelFamilyGemma    ModelFamily = "Gemma"
 	ModelFamilyR1       ModelFamily = "R1"
 
+	ModelFamilyTextEmbedding ModelFamily = "Text Embedding"
+	ModelFamilyTitanEmbed    ModelFamily = "Titan Embed"
+	ModelFamilyCohereEmbed   ModelFamily = "Embed"
+
 	ModelProviderBedrock ModelProvider = "Bedrock"
 	ModelProviderOpenAI  ModelProvider = "Open AI"
 	ModelProviderGroq    ModelProvider = "Groq"
@@ -100,9 +172,9 @@ const (
 	ModelVendorDeepSeek  ModelVendor = "DeepSeek"
 )
 
-func measure(provider Provider, model *Model, prompt *prompt.Prompt) (*Metric, error) {
+func measure(ctx context.Context, provider Provider, model *Model, prompt *prompt.Prompt) (*Metric, error) {
 	start := time.Now()
-	res, err := provider.Send(prompt.Content, model)
+	res, err := provider.SendPrompt(ctx, prompt, model)
 	if err != nil {
 		return nil, err
 	}
@@ -116,17 +188,40 @@ func measure(provider Provider, model *Model, prompt *prompt.Prompt) (*Metric, e
 	}, nil
 }
 
-// filterModels returns models which model name is a substring of filter
-// string. If filter is empty string then all models returned (empty set
-// is a subset of every set). If no models found then empty list returned.
+// measureStream measures a streamed call. The call reports each token
+// which arrived with a given function, the first one is measured as time
+// to first token.
+func measureStream(ctx context.Context, model *Model, call func(ctx context.Context, onToken func()) (*Response, error)) (*Metric, error) {
+	var first time.Duration
+	start := time.Now()
+	res, err := call(ctx, func() {
+		if first == 0 {
+			first = time.Since(start)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metric{
+		Model:      model,
+		Latency:    time.Since(start),
+		FirstToken: first,
+		Response:   res,
+	}, nil
+}
+
+// filterModels returns models which name, ID, family, vendor or provider
+// contains filter string, case-insensitive. If filter is empty string then
+// all models returned (empty set is a subset of every set). If no models
+// found then empty list returned.
 func filterModels(models []Model, filter string) []*Model {
 	// Pre-allocate list enough to hold all models to avoid reallocations.
 	res := make([]*Model, 0, len(models))
+	query := strings.ToLower(filter)
 
 	for _, model := range models {
-		modelName, query := strings.ToLower(model.Name), strings.ToLower(filter)
-
-		if strings.Contains(modelName, query) {
+		if model.matches(query) {
 			modelCopy := model
 			res = append(res, &modelCopy)
 		}
@@ -134,3 +229,16 @@ func filterModels(models []Model, filter string) []*Model {
 
 	return res
 }
+
+// matches reports whether any of model's searchable fields contains
+// lower case query.
+func (m *Model) matches(query string) bool {
+	fields := []string{m.Name, m.ID, string(m.Family), string(m.Vendor), string(m.Provider)}
+	for _, f := range fields {
+		if strings.Contains(strings.ToLower(f), query) {
+			return true
+		}
+	}
+
+	return false
+}
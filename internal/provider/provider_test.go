@@ -0,0 +1,27 @@
+package provider
+
+import "testing"
+
+func TestFilterModels(t *testing.T) {
+	models := []Model{
+		{ID: "gpt-4o", Name: "GPT 4o", Provider: ModelProviderOpenAI, Vendor: ModelVendorOpenAI, Family: ModelFamilyGPT},
+		{ID: "anthropic.claude-v2", Name: "Claude v2", Provider: ModelProviderBedrock, Vendor: ModelVendorAnthropic, Family: ModelFamilyClaude},
+		{ID: "llama3-8b-8192", Name: "Llama3 8b 8192", Provider: ModelProviderGroq, Vendor: ModelVendorMeta, Family: ModelFamilyLlama3},
+	}
+
+	tests := map[string]int{
+		"":          3,
+		"gpt 4o":    1,
+		"anthropic": 1,
+		"bedrock":   1,
+		"LLAMA 3":   1,
+		"8192":      1,
+		"nothing":   0,
+	}
+
+	for filter, want := range tests {
+		if got := filterModels(models, filter); len(got) != want {
+			t.Errorf("filter %q: expected %d models, got %d", filter, want, len(got))
+		}
+	}
+}
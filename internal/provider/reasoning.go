@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/sashabaranov/go-openai"
+)
+
+// reasoningMaxTokens limits output of reasoning models. Hidden reasoning
+// counts towards it, so it leaves room for an answer after reasoning.
+const reasoningMaxTokens = 8192
+
+// SupportsSystemRole reports whether model accepts system messages, either
+// as a model of its own or as a member of a family which has no way to
+// represent them.
+func (m *Model) SupportsSystemRole() bool {
+	return !m.NoSystemRole && !systemlessFamilies[m.Family]
+}
+
+// VisibleOutputTokens returns output tokens of completion and tool calls,
+// that is output tokens without hidden reasoning.
+func (r *Response) VisibleOutputTokens() int {
+	return max(0, r.OutputTokens-r.ReasoningTokens)
+}
+
+// newOpenAIRequest maps prompt onto chat completion request respecting
+// capabilities of a model. Temperature is never sent, models run with
+// their default one, which is the only one reasoning models accept.
+// Reasoning models get a budget of completion tokens instead of max
+// tokens, which they reject, and their reasoning effort if it is set.
+func newOpenAIRequest(p *prompt.Prompt, to *Model) openai.ChatCompletionRequest {
+	req := openai.ChatCompletionRequest{
+		Model:    to.ID,
+		Messages: openAIMessages(p),
+		Tools:    openAITools(p),
+	}
+	if to.Reasoning {
+		req.MaxCompletionTokens = reasoningMaxTokens
+		req.ReasoningEffort = to.ReasoningEffort
+	}
+
+	return req
+}
+
+// openAIReasoningTokens returns reasoning tokens reported in usage, zero
+// if they are not reported.
+func openAIReasoningTokens(usage openai.Usage) int {
+	if usage.CompletionTokensDetails == nil {
+		return 0
+	}
+	return usage.CompletionTokensDetails.ReasoningTokens
+}
+
+// stripThinking removes a `<think>` block which R1 models put before an
+// answer. Providers don't report reasoning tokens of such models, they are
+// estimated as a share of output tokens taken by the block.
+func stripThinking(res *Response) {
+	const closing = "</think>"
+
+	// Opening tag is sometimes omitted, reasoning starts right away.
+	end := strings.Index(res.Completion, closing)
+	if end < 0 {
+		return
+	}
+
+	total := len(res.Completion)
+	end += len(closing)
+	if res.ReasoningTokens == 0 {
+		res.ReasoningTokens = res.OutputTokens * end / total
+	}
+	res.Completion = strings.TrimSpace(res.Completion[end:])
+}
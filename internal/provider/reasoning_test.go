@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestNewOpenAIRequest(t *testing.T) {
+	plain := newOpenAIRequest(conversation, &Model{ID: "gpt-4o"})
+	if plain.Temperature != 0 || plain.MaxCompletionTokens != 0 {
+		t.Errorf("expected default temperature without completion budget, got %+v", plain)
+	}
+
+	reasoning := newOpenAIRequest(conversation, &Model{ID: "o1", Reasoning: true, ReasoningEffort: "low"})
+	if reasoning.Temperature != 0 || reasoning.MaxTokens != 0 || reasoning.MaxCompletionTokens != reasoningMaxTokens {
+		t.Errorf("expected completion budget without temperature, got %+v", reasoning)
+	}
+	if reasoning.ReasoningEffort != "low" {
+		t.Errorf("expected low reasoning effort, got %q", reasoning.ReasoningEffort)
+	}
+}
+
+func TestOpenAIRejectsSystemRole(t *testing.T) {
+	p, err := NewOpenAI("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := p.GetLLMModels("o1-mini")[0]
+	if _, err := p.SendPrompt(context.Background(), conversation, model); !errors.Is(err, ErrSystemPromptUnsupported) {
+		t.Errorf("expected ErrSystemPromptUnsupported, got %v", err)
+	}
+}
+
+func TestOpenAIReasoningTokens(t *testing.T) {
+	usage := openai.Usage{CompletionTokens: 300, CompletionTokensDetails: &openai.CompletionTokensDetails{ReasoningTokens: 256}}
+	if n := openAIReasoningTokens(usage); n != 256 {
+		t.Errorf("expected 256 reasoning tokens, got %d", n)
+	}
+	if n := openAIReasoningTokens(openai.Usage{}); n != 0 {
+		t.Errorf("expected no reasoning tokens, got %d", n)
+	}
+}
+
+func TestStripThinking(t *testing.T) {
+	tests := map[string]string{
+		"<think>\nLet me see.\n</think>\n\nParis.": "Paris.",
+		"Let me see.\n</think>\nParis.":            "Paris.",
+		"Paris.":                                   "Paris.",
+	}
+
+	for completion, want := range tests {
+		res := &Response{Completion: completion, OutputTokens: 100}
+		stripThinking(res)
+		if res.Completion != want {
+			t.Errorf("%q: expected %q, got %q", completion, want, res.Completion)
+		}
+		if strings.Contains(completion, "</think>") != (res.ReasoningTokens > 0) {
+			t.Errorf("%q: unexpected reasoning tokens %d", completion, res.ReasoningTokens)
+		}
+		if res.VisibleOutputTokens()+res.ReasoningTokens != 100 {
+			t.Errorf("%q: expected reasoning to be a part of output tokens", completion)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// streamOpenAI sends a chat completion request of an OpenAI compatible API
+// as a stream and assembles response from its chunks. A given function is
+// called for each chunk which carries content or a tool call.
+func streamOpenAI(ctx context.Context, client *openai.Client, name ModelProvider, req openai.ChatCompletionRequest, onToken func()) (*Response, error) {
+	req.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	stream, err := client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return nil, classifyError(name, err)
+	}
+	defer stream.Close()
+
+	res := &Response{}
+	var completion strings.Builder
+	var calls []openai.ToolCall
+	choices := false
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, classifyError(name, err)
+		}
+
+		// Usage arrives with the last chunk, which has no choices.
+		if u := chunk.Usage; u != nil {
+			res.InputTokens = u.PromptTokens
+			res.OutputTokens = u.CompletionTokens
+			res.CachedInputTokens = openAICachedTokens(*u)
+			res.ReasoningTokens = openAIReasoningTokens(*u)
+		}
+
+		for _, c := range chunk.Choices {
+			choices = true
+			if c.Delta.Content != "" || len(c.Delta.ToolCalls) > 0 {
+				onToken()
+			}
+			completion.WriteString(c.Delta.Content)
+			calls = mergeToolCallDeltas(calls, c.Delta.ToolCalls)
+		}
+	}
+	if !choices {
+		return nil, classifyError(name, errNoChoices)
+	}
+
+	res.Completion = completion.String()
+	res.ToolCalls = openAIToolCalls(openai.ChatCompletionMessage{ToolCalls: calls})
+
+	return res, nil
+}
+
+// mergeToolCallDeltas merges streamed parts of tool calls into calls. The
+// first part of a call carries its name, the rest carry pieces of its
+// arguments, parts of the same call share an index.
+func mergeToolCallDeltas(calls []openai.ToolCall, deltas []openai.ToolCall) []openai.ToolCall {
+	for _, d := range deltas {
+		i := len(calls)
+		if d.Index != nil {
+			i = *d.Index
+		}
+		for len(calls) <= i {
+			calls = append(calls, openai.ToolCall{Type: openai.ToolTypeFunction})
+		}
+
+		c := &calls[i]
+		if d.ID != "" {
+			c.ID = d.ID
+		}
+		c.Function.Name += d.Function.Name
+		c.Function.Arguments += d.Function.Arguments
+	}
+
+	return calls
+}
@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+func TestMeasureStream(t *testing.T) {
+	m, err := measureStream(context.Background(), &Model{ID: "m"}, func(ctx context.Context, onToken func()) (*Response, error) {
+		time.Sleep(10 * time.Millisecond)
+		onToken()
+		time.Sleep(20 * time.Millisecond)
+		onToken()
+		return &Response{Completion: "Hi"}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.FirstToken < 10*time.Millisecond || m.FirstToken >= m.Latency {
+		t.Errorf("expected first token after 10ms and before %v, got %v", m.Latency, m.FirstToken)
+	}
+	if m.Latency < 30*time.Millisecond {
+		t.Errorf("expected latency until the last token, got %v", m.Latency)
+	}
+}
+
+func TestMergeToolCallDeltas(t *testing.T) {
+	zero, one := 0, 1
+	var calls []openai.ToolCall
+	calls = mergeToolCallDeltas(calls, []openai.ToolCall{{Index: &zero, ID: "a", Function: openai.FunctionCall{Name: "get_weather"}}})
+	calls = mergeToolCallDeltas(calls, []openai.ToolCall{{Index: &zero, Function: openai.FunctionCall{Arguments: `{"city":`}}})
+	calls = mergeToolCallDeltas(calls, []openai.ToolCall{{Index: &one, ID: "b", Function: openai.FunctionCall{Name: "get_time", Arguments: "{}"}}})
+	calls = mergeToolCallDeltas(calls, []openai.ToolCall{{Index: &zero, Function: openai.FunctionCall{Arguments: `"Oslo"}`}}})
+
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[0].ID != "a" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Errorf("unexpected first call %+v", calls[0])
+	}
+	if calls[1].ID != "b" || calls[1].Function.Name != "get_time" || calls[1].Function.Arguments != "{}" {
+		t.Errorf("unexpected second call %+v", calls[1])
+	}
+}
+
+func TestBedrockChunks(t *testing.T) {
+	cases := []struct {
+		name   string
+		parser bedrockChunkParser
+		chunks []string
+		want   string
+		cached int
+	}{
+		{"claude", claudeChunk, []string{
+			`{"type":"message_start","message":{"usage":{"input_tokens":10,"cache_read_input_tokens":2100}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hel"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"lo"}}`,
+			`{"type":"message_stop","amazon-bedrock-invocationMetrics":{"inputTokenCount":10,"outputTokenCount":2}}`,
+		}, "Hello", 2100},
+		{"nova", novaChunk, []string{
+			`{"messageStart":{"role":"assistant"}}`,
+			`{"contentBlockDelta":{"delta":{"text":"Hel"},"contentBlockIndex":0}}`,
+			`{"contentBlockDelta":{"delta":{"text":"lo"},"contentBlockIndex":0}}`,
+			`{"metadata":{"usage":{"inputTokens":10,"outputTokens":2,"cacheReadInputTokenCount":2100}}}`,
+		}, "Hello", 2100},
+		{"titan", titanChunk, []string{`{"outputText":"Hel","index":0}`, `{"outputText":"lo","index":0}`}, "Hello", 0},
+		{"llama3", llama3Chunk, []string{`{"generation":"Hel"}`, `{"generation":"lo","stop_reason":"stop"}`}, "Hello", 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := &Response{}
+			var completion strings.Builder
+			for _, chunk := range c.chunks {
+				text, err := c.parser([]byte(chunk), res)
+				if err != nil {
+					t.Fatal(err)
+				}
+				completion.WriteString(text)
+			}
+			if completion.String() != c.want {
+				t.Errorf("expected %q, got %q", c.want, completion.String())
+			}
+			if res.CachedInputTokens != c.cached {
+				t.Errorf("expected %d cached tokens, got %d", c.cached, res.CachedInputTokens)
+			}
+		})
+	}
+}
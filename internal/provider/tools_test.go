@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/sashabaranov/go-openai"
+)
+
+var toolPrompt = &prompt.Prompt{
+	Content: "Weather in Lisbon?",
+	Tools: []prompt.Tool{{
+		Name:        "get_weather",
+		Description: "Get weather.",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"city":{"type":"string","description":"City"}},"required":["city"]}`),
+	}},
+}
+
+func TestOpenAITools(t *testing.T) {
+	tools := openAITools(toolPrompt)
+	if len(tools) != 1 || tools[0].Type != openai.ToolTypeFunction || tools[0].Function.Name != "get_weather" {
+		t.Errorf("unexpected tools: %+v", tools)
+	}
+
+	msg := openai.ChatCompletionMessage{ToolCalls: []openai.ToolCall{{Function: openai.FunctionCall{Name: "get_weather", Arguments: `{"city":"Lisbon"}`}}}}
+	calls := openAIToolCalls(msg)
+	if len(calls) != 1 || calls[0].Name != "get_weather" || calls[0].Arguments != `{"city":"Lisbon"}` {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+}
+
+func TestClaudeTools(t *testing.T) {
+	req, err := json.Marshal(newClaudeRequest(toolPrompt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(req), `"tools":[{"name":"get_weather","description":"Get weather.","input_schema":{"type":"object"`) {
+		t.Errorf("unexpected request: %s", req)
+	}
+
+	var res claudeResponse
+	body := `{"content":[{"type":"text","text":"Checking."},{"type":"tool_use","id":"t1","name":"get_weather","input":{"city":"Lisbon"}}]}`
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatal(err)
+	}
+	if calls := res.toolCalls(); len(calls) != 1 || calls[0].Name != "get_weather" || calls[0].Arguments != `{"city":"Lisbon"}` {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+}
+
+func TestNovaTools(t *testing.T) {
+	req, err := json.Marshal(newNovaRequest(toolPrompt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(req), `"toolConfig":{"tools":[{"toolSpec":{"name":"get_weather","description":"Get weather.","inputSchema":{"json":{"type":"object"`) {
+		t.Errorf("unexpected request: %s", req)
+	}
+
+	var res novaResponse
+	body := `{"output":{"message":{"content":[{"toolUse":{"toolUseId":"t1","name":"get_weather","input":{"city":"Lisbon"}}}]}}}`
+	if err := json.Unmarshal([]byte(body), &res); err != nil {
+		t.Fatal(err)
+	}
+	if calls := res.toolCalls(); len(calls) != 1 || calls[0].Arguments != `{"city":"Lisbon"}` {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+}
+
+func TestCommandRTools(t *testing.T) {
+	req, err := newCommandRRequest(toolPrompt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := commandRParameter{Description: "City", Type: "str", Required: true}
+	if len(req.Tools) != 1 || req.Tools[0].ParameterDefinitions["city"] != want {
+		t.Errorf("unexpected tools: %+v", req.Tools)
+	}
+}
+
+func TestBedrockRejectsTools(t *testing.T) {
+	titan := &Model{Vendor: ModelVendorAmazon, Family: ModelFamilyTitan}
+
+	_, err := (&Bedrock{}).SendPrompt(context.Background(), toolPrompt, titan)
+	if !errors.Is(err, ErrToolsUnsupported) {
+		t.Errorf("expected ErrToolsUnsupported, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+)
+
+// bucket is a token bucket refilled continuously up to its per minute
+// capacity. Nil bucket has no limit.
+type bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+// newBucket returns a full bucket with a given per minute capacity,
+// nil if capacity is not positive.
+func newBucket(perMinute int) *bucket {
+	if perMinute <= 0 {
+		return nil
+	}
+
+	return &bucket{
+		capacity: float64(perMinute),
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// take takes n tokens and returns how long caller has to wait until they
+// are refilled. Tokens are taken immediately even if bucket runs into
+// debt, so concurrent callers queue up behind each other. Negative n
+// returns tokens back.
+func (b *bucket) take(n int) time.Duration {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	rate := b.capacity / time.Minute.Seconds()
+	b.tokens = min(b.capacity, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	b.tokens -= float64(n)
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
@@ -0,0 +1,291 @@
+// Package scheduler bounds how many models are measured at once and how
+// fast requests are sent to each provider. Without it running all models
+// fires every request at once, trips provider rate limits and adds
+// client-side queuing to measured latency.
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/pricing"
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+)
+
+const (
+	// DefaultConcurrency is a number of models measured at once across
+	// all providers.
+	DefaultConcurrency = 8
+
+	// DefaultProviderConcurrency is a number of models of a single
+	// provider measured at once.
+	DefaultProviderConcurrency = 4
+)
+
+// Config of a scheduler. Zero values fall back to defaults, rate limits
+// are disabled unless set.
+type Config struct {
+	// Concurrency limits number of models measured at once.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// Providers holds limits of each provider keyed by provider name.
+	Providers map[provider.ModelProvider]Limits `json:"providers,omitempty"`
+}
+
+// Limits of a single provider.
+type Limits struct {
+	// Concurrency limits number of models of a provider measured at once.
+	Concurrency int `json:"concurrency,omitempty"`
+
+	// RPM limits requests per minute.
+	RPM int `json:"rpm,omitempty"`
+
+	// TPM limits tokens per minute. Tokens of a request are estimated
+	// before it is sent and corrected with reported usage afterwards.
+	TPM int `json:"tpm,omitempty"`
+}
+
+// Scheduler hands out concurrency slots and paces requests of providers.
+type Scheduler struct {
+	config Config
+
+	mu        sync.Mutex
+	global    chan struct{}
+	providers map[provider.ModelProvider]*providerLimiter
+}
+
+type providerLimiter struct {
+	slots chan struct{}
+	rpm   *bucket
+	tpm   *bucket
+}
+
+func New(c Config) *Scheduler {
+	concurrency := c.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	return &Scheduler{
+		config:    c,
+		global:    make(chan struct{}, concurrency),
+		providers: make(map[provider.ModelProvider]*providerLimiter),
+	}
+}
+
+// limiter returns limiter of a provider creating it on first use.
+func (s *Scheduler) limiter(name provider.ModelProvider) *providerLimiter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if l, ok := s.providers[name]; ok {
+		return l
+	}
+
+	limits := s.config.Providers[name]
+	concurrency := limits.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultProviderConcurrency
+	}
+
+	l := &providerLimiter{
+		slots: make(chan struct{}, concurrency),
+		rpm:   newBucket(limits.RPM),
+		tpm:   newBucket(limits.TPM),
+	}
+	s.providers[name] = l
+
+	return l
+}
+
+// Concurrency returns number of models measured at once.
+func (s *Scheduler) Concurrency() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return cap(s.global)
+}
+
+// SetConcurrency changes number of models measured at once, zero falls
+// back to default. New limit applies to models acquiring slots afterwards,
+// models already running or waiting keep the previous one.
+func (s *Scheduler) SetConcurrency(n int) {
+	if n <= 0 {
+		n = DefaultConcurrency
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if n != cap(s.global) {
+		s.global = make(chan struct{}, n)
+	}
+}
+
+// Acquire blocks until a slot of a provider and a global slot are free.
+// Provider slot is taken first so a model waiting for its provider does
+// not hold a global slot other providers could use. Returned function
+// releases both slots and must be called once measurement is done.
+func (s *Scheduler) Acquire(ctx context.Context, name provider.ModelProvider) (func(), error) {
+	l := s.limiter(name)
+
+	select {
+	case l.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	s.mu.Lock()
+	global := s.global
+	s.mu.Unlock()
+
+	select {
+	case global <- struct{}{}:
+	case <-ctx.Done():
+		<-l.slots
+		return nil, ctx.Err()
+	}
+
+	return func() {
+		<-global
+		<-l.slots
+	}, nil
+}
+
+// wait reserves a request and estimated tokens and blocks until rate
+// limits allow sending it. A request which is cancelled while waiting is
+// never sent, its reservation is returned back.
+func (l *providerLimiter) wait(ctx context.Context, tokens int) error {
+	wait := time.NewTimer(max(l.rpm.take(1), l.tpm.take(tokens)))
+	defer wait.Stop()
+
+	select {
+	case <-wait.C:
+		return nil
+	case <-ctx.Done():
+		l.rpm.take(-1)
+		l.tpm.take(-tokens)
+		return ctx.Err()
+	}
+}
+
+// reservationKey is a context key of a reservation made ahead of a call.
+type reservationKey struct{}
+
+// reservation is a request reserved by a limiter ahead of a call, so that
+// the call doesn't wait again.
+type reservation struct {
+	limiter *providerLimiter
+	used    bool
+}
+
+// reserve waits for rate limits and returns context carrying reservation.
+func (l *providerLimiter) reserve(ctx context.Context, tokens int) (context.Context, error) {
+	if err := l.wait(ctx, tokens); err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, reservationKey{}, &reservation{limiter: l}), nil
+}
+
+// acquire waits for rate limits unless context carries an unused
+// reservation of the limiter, which is used up.
+func (l *providerLimiter) acquire(ctx context.Context, tokens int) error {
+	if r, ok := ctx.Value(reservationKey{}).(*reservation); ok && r.limiter == l && !r.used {
+		r.used = true
+		return nil
+	}
+	return l.wait(ctx, tokens)
+}
+
+// Wrap returns provider which measurements are paced by provider's rate
+// limits. Other calls are passed through as is.
+func (s *Scheduler) Wrap(p provider.Provider) provider.Provider {
+	return &limitedProvider{Provider: p, limiter: s.limiter(p.Name())}
+}
+
+type limitedProvider struct {
+	provider.Provider
+	limiter *providerLimiter
+}
+
+// estimatePromptTokens estimates tokens of a request reserved ahead of
+// a call, corrected with reported usage afterwards.
+func estimatePromptTokens(pr *prompt.Prompt) int {
+	return pricing.EstimatePromptTokens(pr) + pricing.DefaultOutputTokens
+}
+
+// Reserve waits for rate limits ahead of a call, see provider.Reserver.
+func (p *limitedProvider) Reserve(ctx context.Context, model *provider.Model, pr *prompt.Prompt) (context.Context, error) {
+	return p.limiter.reserve(ctx, estimatePromptTokens(pr))
+}
+
+// Measure waits for rate limits before measuring unless they were reserved,
+// so waiting is never part of measured latency.
+func (p *limitedProvider) Measure(ctx context.Context, model *provider.Model, pr *prompt.Prompt) (*provider.Metric, error) {
+	estimate := estimatePromptTokens(pr)
+
+	if err := p.limiter.acquire(ctx, estimate); err != nil {
+		return nil, err
+	}
+
+	m, err := p.Provider.Measure(ctx, model, pr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Correct token estimate with usage reported by provider.
+	if used := m.Response.InputTokens + m.Response.OutputTokens; used > 0 {
+		p.limiter.tpm.take(used - estimate)
+	}
+
+	return m, nil
+}
+
+// WrapEmbedder returns embedder which measurements are paced by provider's
+// rate limits, shared with LLM measurements of the same provider.
+func (s *Scheduler) WrapEmbedder(e provider.Embedder) provider.Embedder {
+	return &limitedEmbedder{Embedder: e, limiter: s.limiter(e.Name())}
+}
+
+type limitedEmbedder struct {
+	provider.Embedder
+	limiter *providerLimiter
+}
+
+// estimateInputTokens estimates tokens of a batch of inputs.
+func estimateInputTokens(inputs []string) int {
+	n := 0
+	for _, in := range inputs {
+		n += pricing.EstimateTokens(in)
+	}
+	return n
+}
+
+// ReserveEmbedding waits for rate limits ahead of a call, see
+// provider.EmbeddingReserver.
+func (p *limitedEmbedder) ReserveEmbedding(ctx context.Context, model *provider.Model, inputs []string) (context.Context, error) {
+	return p.limiter.reserve(ctx, estimateInputTokens(inputs))
+}
+
+// MeasureEmbedding waits for rate limits before measuring unless they were
+// reserved, so waiting is never part of measured latency.
+func (p *limitedEmbedder) MeasureEmbedding(ctx context.Context, model *provider.Model, inputs []string) (*provider.EmbeddingMetric, error) {
+	estimate := estimateInputTokens(inputs)
+
+	if err := p.limiter.acquire(ctx, estimate); err != nil {
+		return nil, err
+	}
+
+	m, err := p.Embedder.MeasureEmbedding(ctx, model, inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	if used := m.Response.InputTokens; used > 0 {
+		p.limiter.tpm.take(used - estimate)
+	}
+
+	return m, nil
+}
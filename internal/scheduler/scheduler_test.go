@@ -0,0 +1,184 @@
+package scheduler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/evaluator"
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+)
+
+// echoProvider completes each prompt with its content right away.
+type echoProvider struct{}
+
+func (echoProvider) Name() provider.ModelProvider { return provider.ModelProviderGroq }
+
+func (echoProvider) GetLLMModels(filter string) []*provider.Model { return nil }
+
+func (echoProvider) VerifyAccess() bool { return true }
+
+func (p echoProvider) Send(message string, to *provider.Model) (*provider.Response, error) {
+	return p.SendPrompt(context.Background(), &prompt.Prompt{Content: message}, to)
+}
+
+func (echoProvider) SendPrompt(ctx context.Context, pr *prompt.Prompt, to *provider.Model) (*provider.Response, error) {
+	return &provider.Response{Completion: pr.Content}, ctx.Err()
+}
+
+func (p echoProvider) Measure(ctx context.Context, model *provider.Model, pr *prompt.Prompt) (*provider.Metric, error) {
+	res, err := p.SendPrompt(ctx, pr, model)
+	if err != nil {
+		return nil, err
+	}
+	return &provider.Metric{Model: model, Response: res}, nil
+}
+
+func TestBucketTake(t *testing.T) {
+	now := time.Now()
+	b := newBucket(60)
+	b.now = func() time.Time { return now }
+	b.last = now
+
+	if wait := b.take(60); wait != 0 {
+		t.Errorf("expected full bucket to not wait, got %s", wait)
+	}
+
+	// One token per second is refilled, bucket is empty.
+	if wait := b.take(2); wait != 2*time.Second {
+		t.Errorf("expected to wait 2s, got %s", wait)
+	}
+
+	now = now.Add(5 * time.Second)
+	if wait := b.take(3); wait != 0 {
+		t.Errorf("expected refilled bucket to not wait, got %s", wait)
+	}
+
+	// Returned tokens are available right away.
+	b.take(-10)
+	if wait := b.take(10); wait != 0 {
+		t.Errorf("expected returned tokens to be available, got %s", wait)
+	}
+}
+
+func TestNilBucket(t *testing.T) {
+	if b := newBucket(0); b != nil || b.take(100) != 0 {
+		t.Error("expected no limit without capacity")
+	}
+}
+
+func TestWaitRefundsOnCancel(t *testing.T) {
+	now := time.Now()
+	l := &providerLimiter{rpm: newBucket(1), tpm: newBucket(100)}
+	for _, b := range []*bucket{l.rpm, l.tpm} {
+		b.now = func() time.Time { return now }
+		b.last = now
+	}
+
+	// Drain the request bucket so that the next request has to wait.
+	l.rpm.take(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(ctx, 40); err != context.Canceled {
+		t.Fatalf("expected cancelled wait, got %v", err)
+	}
+
+	if l.rpm.tokens != 0 || l.tpm.tokens != 100 {
+		t.Errorf("expected reservation to be refunded, got %.0f requests and %.0f tokens", l.rpm.tokens, l.tpm.tokens)
+	}
+}
+
+func TestRateLimitWaitIsNotTimed(t *testing.T) {
+	s := New(Config{Providers: map[provider.ModelProvider]Limits{
+		provider.ModelProviderGroq: {RPM: 600},
+	}})
+
+	// Saturate the bucket, each request waits 100ms for a refill which is
+	// longer than timeout of a call.
+	s.limiter(provider.ModelProviderGroq).rpm.take(600)
+
+	res, err := evaluator.NewEvaluator(s.Wrap(echoProvider{}), &provider.Model{ID: "fake", Name: "Fake"}, &prompt.Prompt{Content: "a"}).
+		WithSampleSize(3).
+		WithTimeout(50 * time.Millisecond).
+		WithRetryPolicy(evaluator.RetryPolicy{MaxAttempts: 1}).
+		Evaluate(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sample := range res.Samples {
+		if !sample.Succeeded() {
+			t.Errorf("expected waiting for rate limit to not time out, got %v", sample.Err)
+		}
+	}
+}
+
+func TestAcquireLimitsProvider(t *testing.T) {
+	s := New(Config{
+		Concurrency: 2,
+		Providers: map[provider.ModelProvider]Limits{
+			provider.ModelProviderGroq: {Concurrency: 1},
+		},
+	})
+
+	release, err := s.Acquire(context.Background(), provider.ModelProviderGroq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Second Groq model waits for the first one.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, provider.ModelProviderGroq); err == nil {
+		t.Error("expected provider slot to be taken")
+	}
+
+	// Other provider still has a global slot.
+	releaseOpenAI, err := s.Acquire(context.Background(), provider.ModelProviderOpenAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Global limit is reached.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(ctx, provider.ModelProviderBedrock); err == nil {
+		t.Error("expected global slots to be taken")
+	}
+
+	release()
+	releaseOpenAI()
+
+	if _, err := s.Acquire(context.Background(), provider.ModelProviderGroq); err != nil {
+		t.Errorf("expected released slot to be available: %s", err)
+	}
+}
+
+func TestSetConcurrency(t *testing.T) {
+	s := New(Config{Concurrency: 1})
+
+	release, err := s.Acquire(context.Background(), provider.ModelProviderOpenAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.SetConcurrency(2)
+	if c := s.Concurrency(); c != 2 {
+		t.Errorf("expected concurrency 2, got %d", c)
+	}
+
+	// Slot taken under the previous limit doesn't count against the new one.
+	for i := 0; i < 2; i++ {
+		if _, err := s.Acquire(context.Background(), provider.ModelProviderGroq); err != nil {
+			t.Fatal(err)
+		}
+	}
+	release()
+
+	s.SetConcurrency(0)
+	if c := s.Concurrency(); c != DefaultConcurrency {
+		t.Errorf("expected default concurrency, got %d", c)
+	}
+}
@@ -0,0 +1,276 @@
+// Package schema implements a subset of JSON Schema sufficient to validate
+// structured model responses: `type`, `properties`, `required`,
+// `additionalProperties`, `items`, `enum`, `minItems` and `maxItems`.
+// Schemas which use other keywords are rejected, except annotations such
+// as `title` and `description` which don't constrain documents.
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrInvalidSchema = errors.New("invalid schema")
+	ErrInvalidJSON   = errors.New("invalid JSON")
+	ErrMismatch      = errors.New("document does not match schema")
+)
+
+// Schema is a parsed JSON Schema node.
+type Schema struct {
+	Type                 any                `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+// keywords are keywords of the supported subset, followed by annotations
+// which don't constrain documents.
+var keywords = map[string]bool{
+	"type":                 true,
+	"properties":           true,
+	"required":             true,
+	"additionalProperties": true,
+	"items":                true,
+	"enum":                 true,
+	"minItems":             true,
+	"maxItems":             true,
+
+	"$schema":     true,
+	"$id":         true,
+	"$comment":    true,
+	"title":       true,
+	"description": true,
+	"default":     true,
+	"examples":    true,
+}
+
+// Parse parses a raw JSON Schema. Keywords outside of the supported subset
+// are rejected, ignoring them would pass documents which don't match.
+func Parse(raw []byte) (*Schema, error) {
+	if err := checkKeywords("$", raw); err != nil {
+		return nil, err
+	}
+
+	var s Schema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSchema, err)
+	}
+
+	return &s, nil
+}
+
+// checkKeywords returns ErrInvalidSchema if a schema node at path or any
+// of its subschemas use an unsupported keyword.
+func checkKeywords(path string, raw []byte) error {
+	var node map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &node); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidSchema, path, err)
+	}
+
+	for key := range node {
+		if !keywords[key] {
+			return fmt.Errorf("%w: %s: unsupported keyword %q", ErrInvalidSchema, path, key)
+		}
+	}
+
+	if raw, ok := node["properties"]; ok {
+		var properties map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &properties); err != nil {
+			return fmt.Errorf("%w: %s.properties: %v", ErrInvalidSchema, path, err)
+		}
+		for name, prop := range properties {
+			if err := checkKeywords(path+"."+name, prop); err != nil {
+				return err
+			}
+		}
+	}
+	if raw, ok := node["items"]; ok {
+		if err := checkKeywords(path+"[]", raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate validates raw JSON document against raw JSON Schema.
+func Validate(rawSchema, document []byte) error {
+	s, err := Parse(rawSchema)
+	if err != nil {
+		return err
+	}
+
+	return s.Validate(document)
+}
+
+// Validate validates raw JSON document against the schema.
+func (s *Schema) Validate(document []byte) error {
+	var v any
+	if err := json.Unmarshal(document, &v); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidJSON, err)
+	}
+
+	return s.validate("$", v)
+}
+
+func (s *Schema) validate(path string, v any) error {
+	if s == nil {
+		return nil
+	}
+
+	if err := s.validateType(path, v); err != nil {
+		return err
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if reflect.DeepEqual(e, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %s is not one of %v", ErrMismatch, path, s.Enum)
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		for _, key := range s.Required {
+			if _, ok := val[key]; !ok {
+				return fmt.Errorf("%w: %s.%s is required", ErrMismatch, path, key)
+			}
+		}
+
+		for key, item := range val {
+			prop, ok := s.Properties[key]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					return fmt.Errorf("%w: %s.%s is not allowed", ErrMismatch, path, key)
+				}
+				continue
+			}
+
+			if err := prop.validate(path+"."+key, item); err != nil {
+				return err
+			}
+		}
+
+	case []any:
+		if s.MinItems != nil && len(val) < *s.MinItems {
+			return fmt.Errorf("%w: %s has less than %d items", ErrMismatch, path, *s.MinItems)
+		}
+		if s.MaxItems != nil && len(val) > *s.MaxItems {
+			return fmt.Errorf("%w: %s has more than %d items", ErrMismatch, path, *s.MaxItems)
+		}
+
+		for i, item := range val {
+			if err := s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) validateType(path string, v any) error {
+	var types []string
+	switch t := s.Type.(type) {
+	case nil:
+		return nil
+	case string:
+		types = []string{t}
+	case []any:
+		for _, item := range t {
+			name, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("%w: type must be a string or a list of strings", ErrInvalidSchema)
+			}
+			types = append(types, name)
+		}
+	default:
+		return fmt.Errorf("%w: type must be a string or a list of strings", ErrInvalidSchema)
+	}
+
+	for _, t := range types {
+		if typeOf(v, t) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s must be %s", ErrMismatch, path, strings.Join(types, " or "))
+}
+
+// typeOf reports whether decoded JSON value is of a JSON Schema type.
+func typeOf(v any, t string) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		n, ok := v.(float64)
+		return ok && n == math.Trunc(n)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	default:
+		return false
+	}
+}
+
+// ExtractJSON returns JSON document from a model completion. Models often
+// wrap JSON into markdown code fences or surround it with prose, in which
+// case the outermost object or array is returned.
+func ExtractJSON(completion string) string {
+	s := strings.TrimSpace(completion)
+
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```json")
+		s = strings.TrimPrefix(s, "```")
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+		return strings.TrimSpace(s)
+	}
+
+	if json.Valid([]byte(s)) {
+		return s
+	}
+
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+
+	closing := "}"
+	if s[start] == '[' {
+		closing = "]"
+	}
+
+	end := strings.LastIndex(s, closing)
+	if end < start {
+		return s
+	}
+
+	return s[start : end+1]
+}
@@ -0,0 +1,103 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+)
+
+const personSchema = `{
+	"type": "object",
+	"properties": {
+		"name": {"type": "string"},
+		"age": {"type": "integer"},
+		"tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+		"role": {"enum": ["admin", "user"]}
+	},
+	"required": ["name"],
+	"additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		doc string
+		err error
+	}{
+		{`{"name": "Ann", "age": 30, "tags": ["a"], "role": "user"}`, nil},
+		{`{"name": "Ann"}`, nil},
+		{`{"age": 30}`, ErrMismatch},
+		{`{"name": "Ann", "age": 30.5}`, ErrMismatch},
+		{`{"name": "Ann", "tags": ["a", "b", "c"]}`, ErrMismatch},
+		{`{"name": "Ann", "tags": [1]}`, ErrMismatch},
+		{`{"name": "Ann", "role": "root"}`, ErrMismatch},
+		{`{"name": "Ann", "extra": true}`, ErrMismatch},
+		{`["Ann"]`, ErrMismatch},
+		{`{"name": `, ErrInvalidJSON},
+	}
+
+	for _, tt := range tests {
+		err := Validate([]byte(personSchema), []byte(tt.doc))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected %v, got %v", tt.doc, tt.err, err)
+		}
+	}
+}
+
+func TestValidateTypeList(t *testing.T) {
+	s := `{"type": ["string", "null"]}`
+
+	if err := Validate([]byte(s), []byte(`null`)); err != nil {
+		t.Errorf("expected null to be valid, got %v", err)
+	}
+
+	if err := Validate([]byte(s), []byte(`1`)); !errors.Is(err, ErrMismatch) {
+		t.Errorf("expected mismatch, got %v", err)
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	tests := map[string]string{
+		`{"a": 1}`:                          `{"a": 1}`,
+		"```json\n{\"a\": 1}\n```":          `{"a": 1}`,
+		`Sure! Here it is: {"a": 1}. Enjoy`: `{"a": 1}`,
+		`[1, 2]`:                            `[1, 2]`,
+		`no json`:                           `no json`,
+	}
+
+	for in, want := range tests {
+		if got := ExtractJSON(in); got != want {
+			t.Errorf("ExtractJSON(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestParseRejectsUnsupportedKeywords(t *testing.T) {
+	tests := []string{
+		`{"type": "string", "pattern": "^[a-z]+$"}`,
+		`{"type": "object", "properties": {"age": {"type": "integer", "minimum": 0}}}`,
+		`{"type": "array", "items": {"type": "number", "maximum": 1}}`,
+		`{"anyOf": [{"type": "string"}, {"type": "null"}]}`,
+		`{"type": "string", "format": "email"}`,
+	}
+
+	for _, s := range tests {
+		if _, err := Parse([]byte(s)); !errors.Is(err, ErrInvalidSchema) {
+			t.Errorf("%s: expected ErrInvalidSchema, got %v", s, err)
+		}
+	}
+}
+
+func TestParseAllowsAnnotations(t *testing.T) {
+	s := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "Person",
+		"type": "object",
+		"properties": {"name": {"type": "string", "description": "Full name"}}
+	}`
+
+	if _, err := Parse([]byte(s)); err != nil {
+		t.Errorf("expected annotations to be allowed, got %v", err)
+	}
+	if _, err := Parse([]byte(personSchema)); err != nil {
+		t.Errorf("expected supported keywords to be allowed, got %v", err)
+	}
+}
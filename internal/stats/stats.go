@@ -0,0 +1,141 @@
+// Package stats computes summary statistics of latency samples.
+package stats
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+)
+
+// Mean returns arithmetic mean of samples, zero if there are none.
+func Mean(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	var sum time.Duration
+	for _, s := range samples {
+		sum += s
+	}
+
+	return sum / time.Duration(len(samples))
+}
+
+// Median returns median of samples, zero if there are none.
+func Median(samples []time.Duration) time.Duration {
+	return Percentile(samples, 50)
+}
+
+// Percentile returns p-th percentile of samples, 0 <= p <= 100, linearly
+// interpolated between closest ranks. Returns zero if there are no samples.
+func Percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	weight := rank - float64(lower)
+
+	return sorted[lower] + time.Duration(weight*float64(sorted[upper]-sorted[lower]))
+}
+
+// Min returns the smallest sample, zero if there are none.
+func Min(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	return slices.Min(samples)
+}
+
+// Max returns the largest sample, zero if there are none.
+func Max(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	return slices.Max(samples)
+}
+
+// StdDev returns population standard deviation of samples, zero if there
+// are none.
+func StdDev(samples []time.Duration) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+
+	mean := Mean(samples)
+
+	var varianceSum float64
+	for _, s := range samples {
+		diff := float64(s - mean)
+		varianceSum += diff * diff
+	}
+
+	return time.Duration(math.Sqrt(varianceSum / float64(len(samples))))
+}
+
+// MannWhitney returns two-sided p-value of Mann-Whitney U test of whether
+// samples a and b come from the same distribution. It makes no assumption
+// of normality, which latency rarely has. The normal approximation with tie
+// correction is used, it is rough for fewer than about 8 samples per group.
+// Returns 1 if either group is empty or all samples are equal.
+func MannWhitney(a, b []time.Duration) float64 {
+	n1, n2 := len(a), len(b)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type value struct {
+		d     time.Duration
+		fromA bool
+	}
+	values := make([]value, 0, n1+n2)
+	for _, d := range a {
+		values = append(values, value{d, true})
+	}
+	for _, d := range b {
+		values = append(values, value{d, false})
+	}
+	slices.SortFunc(values, func(x, y value) int {
+		return cmp.Compare(x.d, y.d)
+	})
+
+	// Rank values averaging ranks of ties, and sum ranks of a.
+	var rankSumA, tieSum float64
+	for i := 0; i < len(values); {
+		j := i
+		for j < len(values) && values[j].d == values[i].d {
+			j++
+		}
+
+		rank := float64(i+j+1) / 2
+		for _, v := range values[i:j] {
+			if v.fromA {
+				rankSumA += rank
+			}
+		}
+
+		t := float64(j - i)
+		tieSum += t*t*t - t
+		i = j
+	}
+
+	n := float64(n1 + n2)
+	u := rankSumA - float64(n1*(n1+1))/2
+	mean := float64(n1*n2) / 2
+	variance := float64(n1*n2) / 12 * ((n + 1) - tieSum/(n*(n-1)))
+	if variance <= 0 {
+		return 1
+	}
+
+	// Continuity correction.
+	diff := math.Max(math.Abs(u-mean)-0.5, 0)
+	z := diff / math.Sqrt(variance)
+
+	return math.Erfc(z / math.Sqrt2)
+}
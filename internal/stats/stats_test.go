@@ -0,0 +1,72 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func ms(values ...int) []time.Duration {
+	res := make([]time.Duration, len(values))
+	for i, v := range values {
+		res[i] = time.Duration(v) * time.Millisecond
+	}
+	return res
+}
+
+func TestSummary(t *testing.T) {
+	samples := ms(400, 100, 300, 200)
+
+	cases := []struct {
+		name string
+		got  time.Duration
+		want time.Duration
+	}{
+		{"mean", Mean(samples), 250 * time.Millisecond},
+		{"median", Median(samples), 250 * time.Millisecond},
+		{"median odd", Median(ms(300, 100, 200)), 200 * time.Millisecond},
+		{"p0", Percentile(samples, 0), 100 * time.Millisecond},
+		{"p100", Percentile(samples, 100), 400 * time.Millisecond},
+		{"min", Min(samples), 100 * time.Millisecond},
+		{"max", Max(samples), 400 * time.Millisecond},
+		{"stddev", StdDev(ms(100, 300)), 100 * time.Millisecond},
+	}
+
+	for _, c := range cases {
+		if c.got != c.want {
+			t.Errorf("%s: expected %s, got %s", c.name, c.want, c.got)
+		}
+	}
+
+	// Samples are not reordered.
+	if samples[0] != 400*time.Millisecond {
+		t.Error("expected samples to keep their order")
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	if Mean(nil) != 0 || Median(nil) != 0 || Min(nil) != 0 || Max(nil) != 0 || StdDev(nil) != 0 {
+		t.Error("expected zero for no samples")
+	}
+}
+
+func TestMannWhitney(t *testing.T) {
+	fast := ms(100, 110, 105, 98, 102, 107, 99, 101, 104, 103)
+	slow := ms(200, 210, 190, 205, 198, 202, 207, 195, 201, 199)
+	similar := ms(101, 109, 106, 97, 103, 108, 100, 102, 105, 104)
+
+	if p := MannWhitney(fast, slow); p >= 0.01 {
+		t.Errorf("expected significant difference, got p=%f", p)
+	}
+
+	if p := MannWhitney(fast, similar); p < 0.05 {
+		t.Errorf("expected no significant difference, got p=%f", p)
+	}
+
+	if p := MannWhitney(ms(100, 100), ms(100, 100)); p != 1 {
+		t.Errorf("expected p=1 for equal samples, got %f", p)
+	}
+
+	if p := MannWhitney(nil, slow); p != 1 {
+		t.Errorf("expected p=1 for empty group, got %f", p)
+	}
+}
@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pvlbzn/latai/internal/stats"
+)
+
+// sparkBlocks are block characters of a sparkline from the lowest
+// to the highest.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders samples in order as a line of block characters at
+// most width wide. If there are more samples than width, consecutive
+// samples are averaged so the whole run fits.
+func sparkline(samples []time.Duration, width int) string {
+	if len(samples) == 0 || width <= 0 {
+		return ""
+	}
+
+	points := downsample(samples, width)
+	low, high := stats.Min(points), stats.Max(points)
+
+	var b strings.Builder
+	for _, p := range points {
+		level := len(sparkBlocks) - 1
+		if high > low {
+			level = int(float64(p-low) / float64(high-low) * float64(len(sparkBlocks)-1))
+		}
+		b.WriteRune(sparkBlocks[level])
+	}
+
+	return b.String()
+}
+
+// downsample averages consecutive samples into at most n points.
+func downsample(samples []time.Duration, n int) []time.Duration {
+	if len(samples) <= n {
+		return samples
+	}
+
+	points := make([]time.Duration, n)
+	for i := range points {
+		start := i * len(samples) / n
+		end := (i + 1) * len(samples) / n
+		points[i] = stats.Mean(samples[start:end])
+	}
+
+	return points
+}
+
+// histogram renders distribution of samples as lines of horizontal bars,
+// one line per bin of equal latency range. Bars are scaled so the largest
+// bin is barWidth wide.
+func histogram(samples []time.Duration, bins int, barWidth int) []string {
+	if len(samples) == 0 || bins <= 0 {
+		return nil
+	}
+
+	low, high := stats.Min(samples), stats.Max(samples)
+	if high == low {
+		bins = 1
+	}
+	step := (high - low) / time.Duration(bins)
+
+	counts := make([]int, bins)
+	for _, s := range samples {
+		bin := bins - 1
+		if step > 0 {
+			bin = min(int((s-low)/step), bins-1)
+		}
+		counts[bin]++
+	}
+
+	largest := 0
+	for _, c := range counts {
+		largest = max(largest, c)
+	}
+
+	lines := make([]string, bins)
+	for i, c := range counts {
+		from := low + step*time.Duration(i)
+		to := from + step
+		if i == bins-1 {
+			to = high
+		}
+
+		bar := strings.Repeat("█", c*barWidth/largest)
+		if c > 0 && bar == "" {
+			bar = "▏"
+		}
+
+		label := fmt.Sprintf("%d-%d ms", from.Milliseconds(), to.Milliseconds())
+		lines[i] = fmt.Sprintf("%15s │%s %d", label, bar, c)
+	}
+
+	return lines
+}
@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	lg "github.com/charmbracelet/lipgloss"
+	"github.com/pvlbzn/latai/internal/pricing"
+	"github.com/pvlbzn/latai/internal/stats"
+)
+
+// significance is a p-value below which difference of latency between two
+// models is considered real rather than noise of a small sample.
+const significance = 0.05
+
+// CompareComponent is a modal which shows stats of marked models side by
+// side, highlighting the best value of each metric.
+type CompareComponent struct {
+	width   int
+	visible bool
+
+	// Models being compared and latest results of each row.
+	models  []compareModel
+	results map[int]compareResult
+}
+
+type compareModel struct {
+	rowID int
+	name  string
+}
+
+// compareResult is a result of a latest measurement of a row.
+type compareResult struct {
+	samples      []time.Duration
+	firstToken   []time.Duration
+	outputTokens int
+	errorRate    float64
+	retries      int
+	cost         float64
+	priced       bool
+}
+
+// tokensPerSecond returns output throughput, false if provider reported
+// no output tokens.
+func (r compareResult) tokensPerSecond() (float64, bool) {
+	var total time.Duration
+	for _, s := range r.samples {
+		total += s
+	}
+	if r.outputTokens == 0 || total == 0 {
+		return 0, false
+	}
+
+	return float64(r.outputTokens) / total.Seconds(), true
+}
+
+// compareMetric is a row of comparison, value returns false if a model
+// has no value of the metric.
+type compareMetric struct {
+	name          string
+	value         func(compareResult) (float64, bool)
+	format        func(float64) string
+	lowerIsBetter bool
+}
+
+var compareMetrics = []compareMetric{
+	{
+		name:          "Median",
+		value:         func(r compareResult) (float64, bool) { return float64(stats.Median(r.samples).Milliseconds()), true },
+		format:        func(v float64) string { return fmt.Sprintf("%.0f ms", v) },
+		lowerIsBetter: true,
+	},
+	{
+		name: "p95",
+		value: func(r compareResult) (float64, bool) {
+			return float64(stats.Percentile(r.samples, 95).Milliseconds()), true
+		},
+		format:        func(v float64) string { return fmt.Sprintf("%.0f ms", v) },
+		lowerIsBetter: true,
+	},
+	{
+		name: "TTFT",
+		value: func(r compareResult) (float64, bool) {
+			return float64(stats.Median(r.firstToken).Milliseconds()), len(r.firstToken) > 0
+		},
+		format:        func(v float64) string { return fmt.Sprintf("%.0f ms", v) },
+		lowerIsBetter: true,
+	},
+	{
+		name:   "Tokens/s",
+		value:  compareResult.tokensPerSecond,
+		format: func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	},
+	{
+		name:          "Errors",
+		value:         func(r compareResult) (float64, bool) { return r.errorRate * 100, true },
+		format:        func(v float64) string { return fmt.Sprintf("%.0f%%", v) },
+		lowerIsBetter: true,
+	},
+	{
+		name:          "Retries",
+		value:         func(r compareResult) (float64, bool) { return float64(r.retries), true },
+		format:        func(v float64) string { return fmt.Sprintf("%.0f", v) },
+		lowerIsBetter: true,
+	},
+	{
+		name:          "Cost",
+		value:         func(r compareResult) (float64, bool) { return r.cost, r.priced },
+		format:        pricing.Format,
+		lowerIsBetter: true,
+	},
+}
+
+func NewCompareComponent(width int) *CompareComponent {
+	return &CompareComponent{
+		width:   width,
+		results: make(map[int]compareResult),
+	}
+}
+
+func (s *CompareComponent) Init() tea.Cmd {
+	return nil
+}
+
+func (s *CompareComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	return s, nil
+}
+
+// SetWidth sets inner width of the modal.
+func (s *CompareComponent) SetWidth(width int) {
+	s.width = width
+}
+
+// Visible reports whether comparison is open.
+func (s *CompareComponent) Visible() bool {
+	return s.visible
+}
+
+// Open shows comparison of given models.
+func (s *CompareComponent) Open(models []compareModel) {
+	s.models = models
+	s.visible = true
+}
+
+func (s *CompareComponent) Close() {
+	s.visible = false
+}
+
+// SetResult replaces result of a row with a latest measurement.
+func (s *CompareComponent) SetResult(rowID int, result compareResult) {
+	s.results[rowID] = result
+}
+
+func (s *CompareComponent) View() string {
+	container := lg.NewStyle().
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("241")).
+		Width(s.width)
+
+	header := lg.NewStyle().
+		Bold(true).
+		PaddingLeft(1).
+		Render(fmt.Sprintf("Compare: %d models", len(s.models)))
+
+	separator := lg.NewStyle().
+		Foreground(lg.Color("240")).
+		Render(strings.Repeat("─", s.width))
+
+	help := lg.NewStyle().
+		Foreground(lg.Color("241")).
+		PaddingLeft(1).
+		Render(fmt.Sprintf("* differs from fastest (p < %.2f), ~ within noise | c/esc: close", significance))
+
+	return container.Render(lg.JoinVertical(
+		lg.Top,
+		header,
+		separator,
+		s.renderTable(),
+		separator,
+		help))
+}
+
+// renderTable renders metrics as rows and models as columns.
+func (s *CompareComponent) renderTable() string {
+	labelWidth := 12
+	columnWidth := min(20, (s.width-labelWidth-1)/max(1, len(s.models)))
+
+	label := lg.NewStyle().
+		Width(labelWidth).
+		PaddingLeft(1).
+		Foreground(lg.Color("241"))
+	cell := lg.NewStyle().
+		Width(columnWidth)
+	best := cell.
+		Foreground(lg.Color("42")).
+		Bold(true)
+	missing := cell.
+		Foreground(lg.Color("240"))
+
+	renderRow := func(name string, cells []string) string {
+		return lg.JoinHorizontal(lg.Top, append([]string{label.Render(name)}, cells...)...)
+	}
+
+	// Model names.
+	var names []string
+	for _, m := range s.models {
+		names = append(names, cell.Bold(true).Render(truncate(m.name, columnWidth-1)))
+	}
+	lines := []string{renderRow("", names)}
+
+	// Samples.
+	var counts []string
+	for _, m := range s.models {
+		if r, ok := s.results[m.rowID]; ok {
+			counts = append(counts, cell.Render(fmt.Sprintf("%d", len(r.samples))))
+		} else {
+			counts = append(counts, missing.Render("not run"))
+		}
+	}
+	lines = append(lines, renderRow("Samples", counts))
+
+	// Metrics with the best value highlighted.
+	for _, metric := range compareMetrics {
+		values := make([]float64, len(s.models))
+		present := make([]bool, len(s.models))
+		bestIndex := -1
+		for i, m := range s.models {
+			r, ok := s.results[m.rowID]
+			if !ok {
+				continue
+			}
+
+			values[i], present[i] = metric.value(r)
+			if !present[i] {
+				continue
+			}
+
+			if bestIndex < 0 ||
+				(metric.lowerIsBetter && values[i] < values[bestIndex]) ||
+				(!metric.lowerIsBetter && values[i] > values[bestIndex]) {
+				bestIndex = i
+			}
+		}
+
+		var cells []string
+		for i := range s.models {
+			switch {
+			case !present[i]:
+				cells = append(cells, missing.Render("—"))
+			case i == bestIndex:
+				cells = append(cells, best.Render(metric.format(values[i])))
+			default:
+				cells = append(cells, cell.Render(metric.format(values[i])))
+			}
+		}
+		lines = append(lines, renderRow(metric.name, cells))
+	}
+
+	lines = append(lines, renderRow("vs fastest", s.renderSignificance(cell, missing)))
+
+	return lg.JoinVertical(lg.Top, lines...)
+}
+
+// renderSignificance tests whether latency of each model differs from the
+// model with the lowest median, so that a lucky run isn't mistaken for
+// a faster model.
+func (s *CompareComponent) renderSignificance(cell, missing lg.Style) []string {
+	fastest := -1
+	for i, m := range s.models {
+		r, ok := s.results[m.rowID]
+		if !ok {
+			continue
+		}
+		if fastest < 0 || stats.Median(r.samples) < stats.Median(s.results[s.models[fastest].rowID].samples) {
+			fastest = i
+		}
+	}
+
+	cells := make([]string, len(s.models))
+	for i, m := range s.models {
+		r, ok := s.results[m.rowID]
+		switch {
+		case !ok:
+			cells[i] = missing.Render("—")
+		case i == fastest:
+			cells[i] = cell.Render("fastest")
+		default:
+			p := stats.MannWhitney(r.samples, s.results[s.models[fastest].rowID].samples)
+			mark := "~"
+			if p < significance {
+				mark = "*"
+			}
+			cells[i] = cell.Render(fmt.Sprintf("p=%.3f %s", p, mark))
+		}
+	}
+
+	return cells
+}
+
+// truncate shortens text to a given width marking it with an ellipsis.
+func truncate(text string, width int) string {
+	runes := []rune(text)
+	if len(runes) <= width {
+		return text
+	}
+	return string(runes[:max(0, width-1)]) + "…"
+}
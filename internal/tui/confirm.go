@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	lg "github.com/charmbracelet/lipgloss"
+)
+
+// ConfirmComponent asks user to confirm an action before running it,
+// e.g. an expensive run of all models.
+type ConfirmComponent struct {
+	width     int
+	message   string
+	onConfirm func() tea.Cmd
+	visible   bool
+}
+
+func NewConfirmComponent(width int) *ConfirmComponent {
+	return &ConfirmComponent{width: width}
+}
+
+// SetWidth sets inner width of the dialog.
+func (s *ConfirmComponent) SetWidth(width int) {
+	s.width = width
+}
+
+// Ask shows a message and remembers an action to run on confirmation.
+func (s *ConfirmComponent) Ask(message string, onConfirm func() tea.Cmd) {
+	s.message = message
+	s.onConfirm = onConfirm
+	s.visible = true
+}
+
+// Visible reports whether confirmation is pending.
+func (s *ConfirmComponent) Visible() bool {
+	return s.visible
+}
+
+func (s *ConfirmComponent) Init() tea.Cmd {
+	return nil
+}
+
+// Update runs the action on `y` or `enter` and dismisses it on `n` or `esc`.
+func (s *ConfirmComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+
+	switch key.String() {
+	case "y", "enter":
+		s.visible = false
+		return s, s.onConfirm()
+
+	case "n", "esc":
+		s.visible = false
+	}
+
+	return s, nil
+}
+
+func (s *ConfirmComponent) View() string {
+	container := lg.NewStyle().
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("214")).
+		Width(s.width)
+
+	header := lg.NewStyle().
+		Bold(true).
+		PaddingLeft(1).
+		Render("Confirm")
+
+	separator := lg.NewStyle().
+		Foreground(lg.Color("240")).
+		Render(strings.Repeat("─", s.width))
+
+	rowStyle := lg.NewStyle().
+		Width(s.width).
+		PaddingLeft(1)
+
+	return container.Render(lg.JoinVertical(
+		lg.Top,
+		header,
+		separator,
+		rowStyle.Render(s.message),
+		rowStyle.Foreground(lg.Color("241")).Render("y/enter: proceed | n/esc: cancel")))
+}
@@ -0,0 +1,480 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	lg "github.com/charmbracelet/lipgloss"
+	"github.com/pvlbzn/latai/internal/config"
+	"github.com/pvlbzn/latai/internal/evaluator"
+	"github.com/pvlbzn/latai/internal/pricing"
+	"github.com/pvlbzn/latai/internal/prompt"
+	"github.com/pvlbzn/latai/internal/provider"
+	"github.com/pvlbzn/latai/internal/scheduler"
+	"github.com/pvlbzn/latai/internal/stats"
+)
+
+// Column indices of an embeddings table row. Median latency of each case
+// of the grid of batch sizes and input lengths follows dimensions, error
+// rate is the last column.
+const (
+	embedColumnName = iota
+	embedColumnProvider
+	embedColumnVendor
+	embedColumnDims
+	embedColumnCases
+)
+
+const (
+	// Bounds of name column width of embeddings table.
+	minEmbedNameWidth = 16
+	maxEmbedNameWidth = 32
+
+	// embedCaseWidth is a width of a column of a single case.
+	embedCaseWidth = 8
+)
+
+// EmbeddingsComponent is a tab which measures embedding models of loaded
+// providers. Each model is measured over a grid of batch sizes and input
+// lengths, a row shows median latency of each of them.
+type EmbeddingsComponent struct {
+	table table.Model
+	rows  []table.Row
+	cases []evaluator.EmbeddingCase
+
+	// Models by row ID along with their embedders.
+	models []*embeddingRow
+
+	// Results of finished measurements by row ID.
+	results map[int]*evaluator.EmbeddingEvaluation
+
+	// Scheduler and channel of events shared with LLM table, so that
+	// embedding models take turns with LLMs.
+	scheduler *scheduler.Scheduler
+	events    chan tea.Msg
+
+	// Cancel functions of in-flight measurements by row ID.
+	inFlight map[int]context.CancelFunc
+
+	// Settings of measurements, only timeout applies to embeddings.
+	run config.Run
+
+	// Model prices used for cost estimates.
+	prices pricing.Table
+
+	// Outer width of the table and inner width of details panel.
+	width       int
+	detailWidth int
+
+	logger *LoggerComponent
+}
+
+type embeddingRow struct {
+	embedder provider.Embedder
+	model    *provider.Model
+}
+
+// NewEmbeddingsComponent lists embedding models of providers which serve
+// them, other providers are skipped.
+func NewEmbeddingsComponent(providers []provider.Provider, prices pricing.Table, sched *scheduler.Scheduler, events chan tea.Msg, logger *LoggerComponent) *EmbeddingsComponent {
+	var models []*embeddingRow
+	for _, p := range providers {
+		e, ok := p.(provider.Embedder)
+		if !ok {
+			continue
+		}
+
+		wrapped := sched.WrapEmbedder(e)
+		for _, m := range e.GetEmbeddingModels("") {
+			models = append(models, &embeddingRow{embedder: wrapped, model: m})
+		}
+	}
+
+	cases := evaluator.NewEmbeddingEvaluator(nil, nil).Cases()
+
+	var rows []table.Row
+	for _, r := range models {
+		row := table.Row{r.model.Name, string(r.model.Provider), string(r.model.Vendor), strconv.Itoa(r.model.Dimensions)}
+		for range cases {
+			row = append(row, " ")
+		}
+		rows = append(rows, append(row, " "))
+	}
+
+	t := table.New(
+		table.WithColumns(embeddingColumns(cases, maxEmbedNameWidth)),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(28))
+
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("240")).
+		BorderBottom(true).
+		Bold(true)
+	s.Selected = s.Selected.
+		Foreground(lg.Color("#fff")).
+		Background(lg.Color("#2b5ccc")).
+		Bold(true)
+	t.SetStyles(s)
+
+	return &EmbeddingsComponent{
+		table:     t,
+		rows:      rows,
+		cases:     cases,
+		models:    models,
+		results:   make(map[int]*evaluator.EmbeddingEvaluation),
+		prices:    prices,
+		scheduler: sched,
+		events:    events,
+		inFlight:  make(map[int]context.CancelFunc),
+		logger:    logger,
+	}
+}
+
+// embeddingColumns returns columns of the table with a column per case.
+func embeddingColumns(cases []evaluator.EmbeddingCase, nameWidth int) []table.Column {
+	columns := []table.Column{
+		{Title: "Name", Width: nameWidth},
+		{Title: "Provider", Width: 8},
+		{Title: "Vendor", Width: 8},
+		{Title: "Dims", Width: 5},
+	}
+	for _, c := range cases {
+		columns = append(columns, table.Column{Title: c.String(), Width: embedCaseWidth})
+	}
+
+	return append(columns, table.Column{Title: "Err", Width: 5})
+}
+
+// SetWidth fits the table into a given outer width, border included, and
+// sets inner width of details panel. Name column takes space left by
+// other columns.
+func (s *EmbeddingsComponent) SetWidth(width, detailWidth int) {
+	s.width, s.detailWidth = width, detailWidth
+
+	used := 0
+	for _, c := range embeddingColumns(s.cases, 0)[1:] {
+		used += c.Width + 2
+	}
+	nameWidth := min(maxEmbedNameWidth, max(minEmbedNameWidth, width-2-used-2))
+	s.table.SetColumns(embeddingColumns(s.cases, nameWidth))
+}
+
+// SetHeight fits the table into a given outer height, border and help
+// included.
+func (s *EmbeddingsComponent) SetHeight(height int) {
+	s.table.SetHeight(max(minTableHeight, height-2-lg.Height(s.makeHelpView())))
+}
+
+// SetRun sets settings of measurements started afterwards.
+func (s *EmbeddingsComponent) SetRun(run config.Run) {
+	s.run = run
+}
+
+func (s *EmbeddingsComponent) MoveCursorUp() {
+	s.table.MoveUp(1)
+}
+
+func (s *EmbeddingsComponent) MoveCursorDown() {
+	s.table.MoveDown(1)
+}
+
+func (s *EmbeddingsComponent) View() string {
+	view := s.table.View()
+	if len(s.models) == 0 {
+		view = lg.NewStyle().
+			Foreground(lg.Color("240")).
+			PaddingLeft(1).
+			Render("No loaded provider serves embedding models.")
+	}
+
+	return lg.NewStyle().
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("241")).
+		Render(view + "\n" + s.makeHelpView())
+}
+
+// makeHelpView returns a view of a single help string.
+func (s *EmbeddingsComponent) makeHelpView() string {
+	style := lg.NewStyle().
+		Foreground(lg.Color("241")).
+		PaddingTop(1).
+		PaddingLeft(1)
+
+	if s.width > 0 {
+		style = style.Width(s.width - 2)
+	}
+
+	return style.Render(
+		"enter: run | A: run all | x/X: cancel/all | tab: models | e: events | q: quit\n" +
+			"columns: median ms of batch size × input words")
+}
+
+// DetailsView returns a panel with latency of each case of a selected model.
+func (s *EmbeddingsComponent) DetailsView() string {
+	container := lg.NewStyle().
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("241")).
+		Width(s.detailWidth)
+
+	rowStyle := lg.NewStyle().PaddingLeft(1)
+	header := rowStyle.Bold(true).Render("Embeddings")
+	separator := lg.NewStyle().
+		Foreground(lg.Color("240")).
+		Render(strings.Repeat("─", s.detailWidth))
+
+	id := s.table.Cursor()
+	if id < 0 || id >= len(s.models) {
+		return container.Render(lg.JoinVertical(lg.Top, header, separator))
+	}
+
+	m := s.models[id].model
+	header = rowStyle.Bold(true).Render(fmt.Sprintf("Embeddings: %s | %s | %s | %s", m.Family, m.Name, m.Provider, m.Vendor))
+
+	var lines []string
+	res, ok := s.results[id]
+	if !ok {
+		lines = append(lines, rowStyle.Foreground(lg.Color("240")).
+			Render("Press enter to measure latency versus batch size and input length."))
+	} else {
+		for _, r := range res.Results {
+			latency := r.Latency()
+			lines = append(lines, rowStyle.Foreground(lg.Color("231")).Render(fmt.Sprintf(
+				"%-9s p50: %d\tp95: %d\tTokens: %d\tErr: %s",
+				r.EmbeddingCase, stats.Median(latency).Milliseconds(), stats.Percentile(latency, 95).Milliseconds(),
+				r.InputTokens(), formatRate(r.ErrorRate()))))
+		}
+	}
+	if m.Family == provider.ModelFamilyTitanEmbed {
+		lines = append(lines, rowStyle.Foreground(lg.Color("240")).
+			Render("Titan Embed takes one input per call, a batch is a sequence of calls."))
+	}
+
+	return container.Render(lg.JoinVertical(lg.Top, append([]string{header, separator}, lines...)...))
+}
+
+// MeasureSelected measures a selected model.
+func (s *EmbeddingsComponent) MeasureSelected() tea.Cmd {
+	id := s.table.Cursor()
+	if id < 0 || id >= len(s.models) {
+		return nil
+	}
+
+	if _, ok := s.inFlight[id]; ok {
+		s.logger.Warn(fmt.Sprintf("%s is already being measured", s.models[id].model.Name))
+		return nil
+	}
+
+	s.logger.Push(fmt.Sprintf("Measuring %s embedding latency", s.models[id].model.Name))
+	return s.measure(id)
+}
+
+// IdleRowIDs returns IDs of rows which are not being measured.
+func (s *EmbeddingsComponent) IdleRowIDs() []int {
+	var ids []int
+	for id := range s.models {
+		if _, ok := s.inFlight[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// MeasureRows measures models with given row IDs which are not being
+// measured.
+func (s *EmbeddingsComponent) MeasureRows(ids []int) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, id := range ids {
+		if _, ok := s.inFlight[id]; !ok {
+			cmds = append(cmds, s.measure(id))
+		}
+	}
+
+	s.logger.Push(fmt.Sprintf("Running %d embedding benchmarks", len(cmds)))
+	return tea.Batch(cmds...)
+}
+
+// EstimateRowCost estimates cost of measuring rows with given IDs over
+// all cases. Returns estimated cost, number of calls, and number of models
+// without price which are not included in the estimate.
+func (s *EmbeddingsComponent) EstimateRowCost(ids []int) (float64, int, int) {
+	// Inputs of a case are of the same length, tokens of a single batch
+	// stand for all samples of the case.
+	var inputTokens int
+	for _, c := range s.cases {
+		for _, in := range prompt.EmbeddingInputs(c.BatchSize, c.InputWords, 0) {
+			inputTokens += pricing.EstimateTokens(in) * evaluator.DefaultEmbeddingSampleSize
+		}
+	}
+
+	var cost float64
+	var unpriced int
+	for _, id := range ids {
+		price, ok := s.prices.Lookup(s.models[id].model)
+		if !ok {
+			unpriced++
+			continue
+		}
+		cost += price.Cost(inputTokens, 0)
+	}
+
+	return cost, len(ids) * len(s.cases) * evaluator.DefaultEmbeddingSampleSize, unpriced
+}
+
+func (s *EmbeddingsComponent) measure(id int) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.inFlight[id] = cancel
+	s.setState(id, rowStateQueued)
+
+	return fetchEmbeddingLatencyCmd(ctx, s, id)
+}
+
+// CancelSelected cancels measurement of a selected model. Returns false
+// if the model is not being measured.
+func (s *EmbeddingsComponent) CancelSelected() bool {
+	cancel, ok := s.inFlight[s.table.Cursor()]
+	if ok {
+		cancel()
+	}
+
+	return ok
+}
+
+// CancelAll cancels all in-flight measurements and returns their count.
+func (s *EmbeddingsComponent) CancelAll() int {
+	for _, cancel := range s.inFlight {
+		cancel()
+	}
+
+	return len(s.inFlight)
+}
+
+func (s *EmbeddingsComponent) finish(id int) {
+	if cancel, ok := s.inFlight[id]; ok {
+		cancel()
+		delete(s.inFlight, id)
+	}
+}
+
+// SetRowState shows state of a measurement unless it already finished.
+func (s *EmbeddingsComponent) SetRowState(id int, state string) {
+	if _, ok := s.inFlight[id]; ok {
+		s.setState(id, state)
+	}
+}
+
+// setState shows state in the first case column and clears the rest.
+func (s *EmbeddingsComponent) setState(id int, state string) {
+	row := s.rows[id]
+	for i := embedColumnCases; i < len(row); i++ {
+		row[i] = " "
+	}
+	row[embedColumnCases] = state
+	s.table.SetRows(s.rows)
+}
+
+// UpdateLatency shows result of a finished measurement.
+func (s *EmbeddingsComponent) UpdateLatency(msg embeddingUpdatedMsg) {
+	s.finish(msg.id)
+	s.results[msg.id] = msg.res
+
+	row := s.rows[msg.id]
+	row[embedColumnDims] = strconv.Itoa(msg.res.Dimensions)
+	for i, c := range s.cases {
+		row[embedColumnCases+i] = "-"
+		if r := msg.res.Result(c); r != nil && len(r.Latency()) > 0 {
+			row[embedColumnCases+i] = strconv.FormatInt(r.Median().Milliseconds(), 10)
+		}
+	}
+	row[len(row)-1] = formatRate(msg.res.ErrorRate())
+	s.table.SetRows(s.rows)
+}
+
+// SetLatencyError marks a model which measurement failed with a label of
+// the error class.
+func (s *EmbeddingsComponent) SetLatencyError(id int, label string) {
+	s.finish(id)
+	s.setState(id, label)
+}
+
+// SetCancelled marks a model which measurement was cancelled.
+func (s *EmbeddingsComponent) SetCancelled(id int) {
+	s.finish(id)
+	s.setState(id, "cancel")
+}
+
+// fetchEmbeddingLatencyCmd measures an embedding model. Cancelling context
+// aborts the measurement at any stage, including waiting in the queue.
+func fetchEmbeddingLatencyCmd(ctx context.Context, s *EmbeddingsComponent, id int) tea.Cmd {
+	// Settings may change while the measurement waits in the queue.
+	run := s.run
+	row := s.models[id]
+
+	return func() tea.Msg {
+		release, err := s.scheduler.Acquire(ctx, row.model.Provider)
+		if err != nil {
+			return embeddingCancelledMsg{id, row.model.Name}
+		}
+		defer release()
+		s.events <- embeddingStateMsg{id, rowStateRunning}
+
+		res, err := evaluator.NewEmbeddingEvaluator(row.embedder, row.model).
+			WithTimeout(run.Timeout()).
+			Evaluate(ctx)
+		if ctx.Err() != nil {
+			return embeddingCancelledMsg{id, row.model.Name}
+		}
+		if err != nil {
+			return embeddingErrMsg{id: id, name: row.model.Name, label: errorLabel(err), err: err.Error()}
+		}
+
+		return embeddingUpdatedMsg{id: id, res: res}
+	}
+}
+
+type embeddingUpdatedMsg struct {
+	id  int
+	res *evaluator.EmbeddingEvaluation
+}
+
+type embeddingErrMsg struct {
+	id   int
+	name string
+
+	// Label of error class, such as `throttled`, and full error message.
+	label string
+	err   string
+}
+
+type embeddingCancelledMsg struct {
+	id   int
+	name string
+}
+
+// embeddingStateMsg reports a change of measurement state of a row of
+// embeddings table.
+type embeddingStateMsg struct {
+	id    int
+	state string
+}
+
+// formatEmbedding summarizes a measurement as median latency of the
+// smallest and the largest case.
+func formatEmbedding(res *evaluator.EmbeddingEvaluation) string {
+	if len(res.Results) == 0 {
+		return "no results"
+	}
+
+	first, last := res.Results[0], res.Results[len(res.Results)-1]
+	return fmt.Sprintf("%s %d ms, %s %d ms, %d dims, errors %s",
+		first.EmbeddingCase, first.Median().Milliseconds(),
+		last.EmbeddingCase, last.Median().Milliseconds(),
+		res.Dimensions, formatRate(res.ErrorRate()))
+}
@@ -4,7 +4,8 @@ import (
 	"fmt"
 	tea "github.com/charmbracelet/bubbletea"
 	lg "github.com/charmbracelet/lipgloss"
-	"math"
+	"github.com/pvlbzn/latai/internal/evaluator"
+	"github.com/pvlbzn/latai/internal/stats"
 	"strings"
 	"time"
 )
@@ -20,54 +21,34 @@ type InfoComponent struct {
 type modelInfo struct {
 	rowID   int
 	avg     string
+	cost    string
 	samples []time.Duration
-}
+	retries int
 
-func (s *modelInfo) getMaxLatency() time.Duration {
-	if len(s.samples) == 0 {
-		return 0
-	}
-	max := s.samples[0]
-	for _, latency := range s.samples {
-		if latency > max {
-			max = latency
-		}
-	}
-	return max
-}
+	// cache summarizes prompt caching measurement, empty unless run in
+	// cache mode.
+	cache string
 
-func (s *modelInfo) getMinLatency() time.Duration {
-	if len(s.samples) == 0 {
-		return 0
-	}
-	min := s.samples[0]
-	for _, latency := range s.samples {
-		if latency < min {
-			min = latency
-		}
-	}
-	return min
-}
+	// structured summarizes structured output measurement, empty unless
+	// some prompt requested it.
+	structured string
 
-func (s *modelInfo) getJitter() time.Duration {
-	if len(s.samples) == 0 {
-		return 0
-	}
+	// vision summarizes vision measurement, empty unless some prompt
+	// attached images.
+	vision string
 
-	var sum time.Duration
-	for _, latency := range s.samples {
-		sum += latency
-	}
-	mean := sum / time.Duration(len(s.samples))
+	// tools summarizes latency of tool calling samples, empty if no
+	// prompt declared tools.
+	tools string
 
-	var varianceSum float64
-	for _, latency := range s.samples {
-		diff := float64(latency - mean)
-		varianceSum += diff * diff
-	}
-	variance := varianceSum / float64(len(s.samples))
+	// reasoning summarizes output tokens spent on hidden reasoning, empty
+	// unless model reported them.
+	reasoning string
 
-	return time.Duration(math.Sqrt(variance))
+	// Number of finished samples, failed ones included, and total number
+	// of samples while measurement is running, zero once it's done.
+	done  int
+	total int
 }
 
 // NewInfoComponent creates an instance of a new panel which displays
@@ -81,6 +62,11 @@ func NewInfoComponent(width int) *InfoComponent {
 	}
 }
 
+// SetWidth sets inner width of the panel.
+func (s *InfoComponent) SetWidth(width int) {
+	s.width = width
+}
+
 func (s *InfoComponent) Init() tea.Cmd {
 	return nil
 }
@@ -134,12 +120,47 @@ func (s *InfoComponent) View() string {
 			Foreground(lg.Color("240")).
 			Render("Press enter to run a measurement.")
 	} else {
+		runs := fmt.Sprintf("%d", len(info.samples))
+		if info.total > 0 {
+			runs = fmt.Sprintf("%d/%d", info.done, info.total)
+		}
+
 		data := fmt.Sprintf(
-			"Runs: %d\tAvg: %s\tMin: %d\tMax: %d\tJitter: %d",
-			len(info.samples), info.avg, info.getMinLatency().Milliseconds(), info.getMaxLatency().Milliseconds(), info.getJitter().Milliseconds())
+			"Runs: %s\tAvg: %s\tMin: %d\tMax: %d\tJitter: %d\tRetries: %d\tCost: %s",
+			runs, info.avg, stats.Min(info.samples).Milliseconds(), stats.Max(info.samples).Milliseconds(), stats.StdDev(info.samples).Milliseconds(), info.retries, info.cost)
 		content = rowStyle.
 			Foreground(lg.Color("231")).
 			Render(fmt.Sprintf(data))
+
+		if info.cache != "" {
+			content = lg.JoinVertical(lg.Top, content, rowStyle.
+				Foreground(lg.Color("231")).
+				Render("Cache: "+info.cache))
+		}
+		if info.structured != "" {
+			content = lg.JoinVertical(lg.Top, content, rowStyle.
+				Foreground(lg.Color("231")).
+				Render("Structured: "+info.structured))
+		}
+		if info.vision != "" {
+			content = lg.JoinVertical(lg.Top, content, rowStyle.
+				Foreground(lg.Color("231")).
+				Render("Vision: "+info.vision))
+		}
+		if info.tools != "" {
+			content = lg.JoinVertical(lg.Top, content, rowStyle.
+				Foreground(lg.Color("231")).
+				Render("Tools: "+info.tools))
+		}
+		if info.reasoning != "" {
+			content = lg.JoinVertical(lg.Top, content, rowStyle.
+				Foreground(lg.Color("231")).
+				Render("Reasoning: "+info.reasoning))
+		}
+
+		if charts := s.makeChartsView(info); charts != "" {
+			content = lg.JoinVertical(lg.Top, content, charts)
+		}
 	}
 
 	return container.Render(lg.JoinVertical(
@@ -149,11 +170,153 @@ func (s *InfoComponent) View() string {
 		content))
 }
 
-func (s *InfoComponent) AddInfo(rowID int, avg string, samples []time.Duration) {
+// makeChartsView returns a sparkline of samples in order, which reveals
+// warm-up and drift, and a histogram of their distribution. Charts need
+// at least two samples.
+func (s *InfoComponent) makeChartsView(info modelInfo) string {
+	if len(info.samples) < 2 {
+		return ""
+	}
+
+	label := lg.NewStyle().
+		Foreground(lg.Color("241")).
+		PaddingLeft(1)
+	chart := lg.NewStyle().
+		Foreground(lg.Color("39")).
+		PaddingLeft(1)
+
+	lines := []string{
+		"",
+		label.Render("Samples in order"),
+		chart.Render(sparkline(info.samples, max(1, s.width-2))),
+		label.Render("Distribution"),
+	}
+	for _, line := range histogram(info.samples, 6, max(10, s.width-30)) {
+		lines = append(lines, chart.Render(line))
+	}
+
+	return lg.JoinVertical(lg.Top, lines...)
+}
+
+func (s *InfoComponent) AddInfo(rowID int, avg string, samples []time.Duration, retries int, cost string) {
 
 	s.info[rowID] = modelInfo{
 		rowID:   rowID,
 		avg:     avg,
+		cost:    cost,
 		samples: samples,
+		retries: retries,
 	}
 }
+
+// SetCache sets summary of prompt caching measurement of a model.
+func (s *InfoComponent) SetCache(rowID int, summary string) {
+	if info, ok := s.info[rowID]; ok {
+		info.cache = summary
+		s.info[rowID] = info
+	}
+}
+
+// formatCache summarizes prompt caching measurement: latency of the first
+// sample which writes the cache against median of the rest, and how many
+// of them reported cache hits.
+func formatCache(c *evaluator.CacheResult) string {
+	return fmt.Sprintf("first %d ms vs cached p50 %d ms, hits %d/%d, %d cached tokens",
+		c.First.Milliseconds(), stats.Median(c.Cached).Milliseconds(), c.Hits, len(c.Cached), c.CachedTokens)
+}
+
+// SetStructured sets summary of structured output measurement of a model.
+func (s *InfoComponent) SetStructured(rowID int, summary string) {
+	if info, ok := s.info[rowID]; ok {
+		info.structured = summary
+		s.info[rowID] = info
+	}
+}
+
+// formatStructured summarizes latency of structured output against free
+// text, if any ran, and share of valid JSON documents. Share of documents
+// valid once extracted from code fences or prose is added if it differs.
+func formatStructured(r *evaluator.StructuredResult) string {
+	res := fmt.Sprintf("p50 %d ms", stats.Median(r.Structured).Milliseconds())
+	if len(r.FreeText) > 0 {
+		res += fmt.Sprintf(" vs free text p50 %d ms", stats.Median(r.FreeText).Milliseconds())
+	}
+
+	res += fmt.Sprintf(", valid %d/%d (%s)", r.Valid, len(r.Structured), formatRate(r.ValidityRate()))
+	if r.Extracted > r.Valid {
+		res += fmt.Sprintf(", extracted %d/%d (%s)", r.Extracted, len(r.Structured), formatRate(r.ExtractedRate()))
+	}
+
+	return res
+}
+
+// SetVision sets summary of vision measurement of a model.
+func (s *InfoComponent) SetVision(rowID int, summary string) {
+	if info, ok := s.info[rowID]; ok {
+		info.vision = summary
+		s.info[rowID] = info
+	}
+}
+
+// formatVision summarizes latency of samples with images and, if any ran,
+// their premium over the same prompts without images.
+func formatVision(r *evaluator.VisionResult) string {
+	image := stats.Median(r.Image)
+	res := fmt.Sprintf("p50 %d ms over %d calls", image.Milliseconds(), len(r.Image))
+	if len(r.Text) > 0 {
+		text := stats.Median(r.Text)
+		res += fmt.Sprintf(" vs text p50 %d ms, premium %+d ms", text.Milliseconds(), (image - text).Milliseconds())
+	}
+
+	return res
+}
+
+// SetTools sets summary of tool calling latency of a model.
+func (s *InfoComponent) SetTools(rowID int, summary string) {
+	if info, ok := s.info[rowID]; ok {
+		info.tools = summary
+		s.info[rowID] = info
+	}
+}
+
+// SetReasoning sets summary of reasoning tokens of a model.
+func (s *InfoComponent) SetReasoning(rowID int, summary string) {
+	if info, ok := s.info[rowID]; ok {
+		info.reasoning = summary
+		s.info[rowID] = info
+	}
+}
+
+// formatReasoning summarizes output tokens of a call spent on hidden
+// reasoning against visible output.
+func formatReasoning(r *evaluator.ReasoningResult) string {
+	share := float64(r.Reasoning) / float64(r.Reasoning+r.Visible)
+	return fmt.Sprintf("~%d hidden vs %d visible output tokens per call (%s hidden)", r.Reasoning, r.Visible, formatRate(share))
+}
+
+// formatToolLatency summarizes latency of tool calling samples along with
+// latency of plain completions, if any ran.
+func formatToolLatency(tools, text []time.Duration) string {
+	res := fmt.Sprintf("p50 %d ms, p95 %d ms over %d calls",
+		stats.Median(tools).Milliseconds(), stats.Percentile(tools, 95).Milliseconds(), len(tools))
+	if len(text) > 0 {
+		res += fmt.Sprintf(" vs text p50 %d ms", stats.Median(text).Milliseconds())
+	}
+
+	return res
+}
+
+// AddProgress updates info of a running measurement with latency of
+// successful samples, number of finished samples out of total and retries
+// made so far.
+func (s *InfoComponent) AddProgress(rowID int, samples []time.Duration, done, total, retries int) {
+	info := s.info[rowID]
+	info.rowID = rowID
+	info.avg = fmt.Sprintf("%d", stats.Mean(samples).Milliseconds())
+	info.cost = "..."
+	info.samples = samples
+	info.done = done
+	info.total = total
+	info.retries = retries
+	s.info[rowID] = info
+}
@@ -0,0 +1,52 @@
+package tui
+
+import lg "github.com/charmbracelet/lipgloss"
+
+const (
+	// minPanelWidth is an inner width of info and events panels below
+	// which they are stacked instead of placed side by side.
+	minPanelWidth = 60
+
+	// maxModalWidth is an inner width modals don't grow beyond, long
+	// lines are hard to read.
+	maxModalWidth = 100
+)
+
+// sideBySide reports whether info and events panels fit side by side.
+func (m *TUIModel) sideBySide() bool {
+	return m.width >= 2*(minPanelWidth+2)
+}
+
+// resize fits components into terminal size. Table height depends on
+// height of panels below it and is set by fitTable.
+func (m *TUIModel) resize() {
+	m.tableComponent.SetWidth(m.width)
+
+	// Inner width of panels, borders take two characters each.
+	panelWidth := m.width - 2
+	if m.sideBySide() {
+		panelWidth = m.width/2 - 2
+	}
+	m.infoComponent.SetWidth(panelWidth)
+	m.confirmComponent.SetWidth(panelWidth)
+	m.loggerComponent.SetWidth(panelWidth)
+	m.embeddingsComponent.SetWidth(m.width, panelWidth)
+
+	modalWidth := min(maxModalWidth, m.width-2)
+	m.compareComponent.SetWidth(modalWidth)
+	m.settingsComponent.SetWidth(modalWidth)
+	m.viewerComponent.SetSize(modalWidth, m.height-2)
+	m.logViewerComponent.SetSize(modalWidth, m.height-2)
+}
+
+// fitTable sets height of tables to height left by tabs and panels, which
+// changes with content of panels.
+func (m *TUIModel) fitTable() {
+	if m.height == 0 {
+		return
+	}
+
+	height := m.height - lg.Height(m.makePanelsView()) - lg.Height(m.makeTabsView())
+	m.tableComponent.SetHeight(height)
+	m.embeddingsComponent.SetHeight(height)
+}
@@ -2,32 +2,77 @@ package tui
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	lg "github.com/charmbracelet/lipgloss"
 )
 
+// LoggerComponent is a panel of recent events. It keeps every event with
+// its full message, the panel shows the last few of them in a single line
+// each while LogViewerComponent shows all of them in full. Events are
+// pushed from commands running concurrently, so access is synchronized.
 type LoggerComponent struct {
+	mu sync.Mutex
+
 	width int
 	logs  []log
 
 	showLast int
+
+	// errorsOnly hides events below error level.
+	errorsOnly bool
+}
+
+type logLevel int
+
+const (
+	levelInfo logLevel = iota
+	levelWarn
+	levelError
+)
+
+func (l logLevel) String() string {
+	switch l {
+	case levelWarn:
+		return "WARN"
+	case levelError:
+		return "ERROR"
+	default:
+		return "INFO"
+	}
+}
+
+// color returns foreground color of events of a level.
+func (l logLevel) color() lg.Color {
+	switch l {
+	case levelWarn:
+		return lg.Color("214")
+	case levelError:
+		return lg.Color("196")
+	default:
+		return lg.Color("252")
+	}
 }
 
 type log struct {
 	id      int
 	time    string
+	level   logLevel
 	message string
 }
 
-func newLog(id int, message string) log {
+func newLog(id int, level logLevel, message string) log {
 	t := time.Now()
 
 	return log{
 		id:      id,
 		time:    fmt.Sprintf("%02d:%02d:%02d", t.Hour(), t.Minute(), t.Second()),
+		level:   level,
 		message: message,
 	}
 }
@@ -45,6 +90,11 @@ func (m *LoggerComponent) WithShowLast(n int) *LoggerComponent {
 	return m
 }
 
+// SetWidth sets inner width of the panel.
+func (m *LoggerComponent) SetWidth(width int) {
+	m.width = width
+}
+
 func (m *LoggerComponent) Init() tea.Cmd {
 	return nil
 }
@@ -60,10 +110,14 @@ func (m *LoggerComponent) View() string {
 		BorderForeground(lg.Color("241")).
 		Width(m.width)
 
+	title := "Events | e: open"
+	if m.ErrorsOnly() {
+		title = "Events, errors only | e: open"
+	}
 	header := lg.NewStyle().
 		Bold(true).
 		PaddingLeft(1).
-		Render("Events")
+		Render(title)
 
 	separator := lg.NewStyle().
 		Foreground(lg.Color("240")).
@@ -72,23 +126,27 @@ func (m *LoggerComponent) View() string {
 	rowStyle := lg.NewStyle().
 		PaddingLeft(1)
 
+	logs := m.Logs()
+
 	var messages []string
-	if len(m.logs) == 0 {
+	if len(logs) == 0 {
 		messages = append(
 			messages,
 			rowStyle.
 				Foreground(lg.Color("240")).
 				Render("No records..."))
 	} else {
-		// Render in a stack style.
-		for i := len(m.logs); i > 0; i-- {
-			if len(m.logs)-i > m.showLast {
+		// Render in a stack style, one line per event.
+		for i := len(logs); i > 0; i-- {
+			if len(logs)-i > m.showLast {
 				break
 			}
 
-			log := m.logs[i-1]
-			row := fmt.Sprintf("%d\t%s\t%s", log.id, log.time, log.message)
-			messages = append(messages, rowStyle.Render(row))
+			log := logs[i-1]
+			row := fmt.Sprintf("%d  %s  %s", log.id, log.time, firstLine(log.message))
+			messages = append(messages, rowStyle.
+				Foreground(log.level.color()).
+				Render(truncate(row, m.width-1)))
 		}
 	}
 
@@ -100,7 +158,92 @@ func (m *LoggerComponent) View() string {
 	))
 }
 
+// firstLine returns the first line of a message marking that it has more.
+func firstLine(message string) string {
+	line, _, more := strings.Cut(message, "\n")
+	if more {
+		return line + " …"
+	}
+	return line
+}
+
+// Push logs an informational event.
 func (m *LoggerComponent) Push(message string) {
+	m.push(levelInfo, message)
+}
+
+// Warn logs an event which needs attention but doesn't stop anything.
+func (m *LoggerComponent) Warn(message string) {
+	m.push(levelWarn, message)
+}
+
+// Error logs a failure. Message is kept in full, e.g. a whole response
+// body returned by a provider.
+func (m *LoggerComponent) Error(message string) {
+	m.push(levelError, message)
+}
+
+func (m *LoggerComponent) push(level logLevel, message string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	id := len(m.logs)
-	m.logs = append(m.logs, newLog(id, message))
+	m.logs = append(m.logs, newLog(id, level, message))
+}
+
+// Logs returns events passing errors only filter.
+func (m *LoggerComponent) Logs() []log {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.errorsOnly {
+		return append([]log(nil), m.logs...)
+	}
+
+	var logs []log
+	for _, l := range m.logs {
+		if l.level == levelError {
+			logs = append(logs, l)
+		}
+	}
+
+	return logs
+}
+
+// ToggleErrorsOnly hides or shows events below error level.
+func (m *LoggerComponent) ToggleErrorsOnly() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.errorsOnly = !m.errorsOnly
+}
+
+// ErrorsOnly reports whether events below error level are hidden.
+func (m *LoggerComponent) ErrorsOnly() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.errorsOnly
+}
+
+// Export writes all events in full, regardless of filter, to a new file
+// in a given directory and returns its path.
+func (m *LoggerComponent) Export(dir string) (string, error) {
+	m.mu.Lock()
+	var b strings.Builder
+	for _, l := range m.logs {
+		fmt.Fprintf(&b, "%d\t%s\t%s\t%s\n", l.id, l.time, l.level, l.message)
+	}
+	m.mu.Unlock()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fmt.Sprintf("latai-%s.log", time.Now().Format("20060102-150405")))
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+
+	return path, nil
 }
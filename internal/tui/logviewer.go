@@ -0,0 +1,135 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	lg "github.com/charmbracelet/lipgloss"
+)
+
+// LogViewerComponent is a modal which lists all events of a logger with
+// full messages, so errors of a large run can be read after they scrolled
+// out of the events panel.
+type LogViewerComponent struct {
+	width  int
+	height int
+
+	logger   *LoggerComponent
+	viewport viewport.Model
+	visible  bool
+}
+
+func NewLogViewerComponent(logger *LoggerComponent, width, height int) *LogViewerComponent {
+	return &LogViewerComponent{
+		width:    width,
+		height:   height,
+		logger:   logger,
+		viewport: viewport.New(width, height-4),
+	}
+}
+
+func (s *LogViewerComponent) Init() tea.Cmd {
+	return nil
+}
+
+// Update scrolls the log.
+func (s *LogViewerComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return s, cmd
+}
+
+// SetSize sets inner size of the modal.
+func (s *LogViewerComponent) SetSize(width, height int) {
+	s.width, s.height = width, height
+	s.viewport.Width = width
+	s.viewport.Height = max(1, height-4)
+	s.Refresh()
+}
+
+// Visible reports whether log viewer is open.
+func (s *LogViewerComponent) Visible() bool {
+	return s.visible
+}
+
+// Open shows the log scrolled to the latest event.
+func (s *LogViewerComponent) Open() {
+	s.visible = true
+	s.Refresh()
+	s.viewport.GotoBottom()
+}
+
+func (s *LogViewerComponent) Close() {
+	s.visible = false
+}
+
+// Refresh renders events again, keeping scroll position unless the log
+// was scrolled to the latest event.
+func (s *LogViewerComponent) Refresh() {
+	if !s.visible {
+		return
+	}
+
+	follow := s.viewport.AtBottom()
+	s.viewport.SetContent(s.renderLogs())
+	if follow {
+		s.viewport.GotoBottom()
+	}
+}
+
+func (s *LogViewerComponent) View() string {
+	container := lg.NewStyle().
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("241")).
+		Width(s.width)
+
+	title := "Events"
+	if s.logger.ErrorsOnly() {
+		title = "Events, errors only"
+	}
+	header := lg.NewStyle().
+		Bold(true).
+		PaddingLeft(1).
+		Render(title)
+
+	separator := lg.NewStyle().
+		Foreground(lg.Color("240")).
+		Render(strings.Repeat("─", s.width))
+
+	help := lg.NewStyle().
+		Foreground(lg.Color("241")).
+		PaddingLeft(1).
+		Render(fmt.Sprintf("j/k: scroll | f: errors only | w: save to file | e/esc: close | %3.f%%", s.viewport.ScrollPercent()*100))
+
+	return container.Render(lg.JoinVertical(
+		lg.Top,
+		header,
+		separator,
+		s.viewport.View(),
+		help))
+}
+
+func (s *LogViewerComponent) renderLogs() string {
+	logs := s.logger.Logs()
+	if len(logs) == 0 {
+		return lg.NewStyle().
+			Foreground(lg.Color("240")).
+			PaddingLeft(1).
+			Render("No records...")
+	}
+
+	muted := lg.NewStyle().Foreground(lg.Color("240"))
+	text := lg.NewStyle().Width(s.width - 2).PaddingLeft(1)
+
+	var b strings.Builder
+	for _, l := range logs {
+		prefix := muted.Render(fmt.Sprintf("%d  %s  ", l.id, l.time)) +
+			lg.NewStyle().Foreground(l.level.color()).Bold(true).Render(l.level.String())
+		b.WriteString(text.Render(prefix + "  " + l.message))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,253 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	lg "github.com/charmbracelet/lipgloss"
+	"github.com/pvlbzn/latai/internal/config"
+	"github.com/pvlbzn/latai/internal/evaluator"
+)
+
+// Steps of numeric settings. Values set in config file may fall between
+// steps, changing them moves to the nearest step.
+var (
+	sampleSizeSteps  = []int{0, 1, 3, 5, 10, 20, 30, 50, 100}
+	timeoutSteps     = []int{0, 5, 10, 15, 30, 60, 120, 300}
+	concurrencySteps = []int{1, 2, 4, 6, 8, 12, 16, 24, 32}
+	warmupSteps      = []int{0, 1, 2, 3, 5, 10}
+
+	promptModes = []evaluator.PromptMode{
+		evaluator.PromptModeAuto,
+		evaluator.PromptModeUnique,
+		evaluator.PromptModeRandom,
+		evaluator.PromptModeCache,
+		evaluator.PromptModeTools,
+		evaluator.PromptModeStructured,
+		evaluator.PromptModeVision,
+	}
+)
+
+// SettingsComponent is a modal which adjusts how models are measured
+// during a session. Changes apply to measurements started afterwards.
+type SettingsComponent struct {
+	width   int
+	visible bool
+	cursor  int
+
+	run         config.Run
+	concurrency int
+}
+
+// setting is a single line of settings, step changes its value in a given
+// direction.
+type setting struct {
+	name   string
+	format func(s *SettingsComponent) string
+	step   func(s *SettingsComponent, dir int)
+}
+
+var settings = []setting{
+	{
+		name:   "Sample size",
+		format: func(s *SettingsComponent) string { return formatSampleSize(s.run.SampleSize) },
+		step: func(s *SettingsComponent, dir int) {
+			s.run.SampleSize = stepValue(sampleSizeSteps, s.run.SampleSize, dir)
+		},
+	},
+	{
+		name:   "Prompt mode",
+		format: func(s *SettingsComponent) string { return formatPromptMode(s.run.PromptMode) },
+		step: func(s *SettingsComponent, dir int) {
+			i := max(0, slices.Index(promptModes, s.run.PromptMode))
+			s.run.PromptMode = promptModes[(i+dir+len(promptModes))%len(promptModes)]
+		},
+	},
+	{
+		name:   "Timeout",
+		format: func(s *SettingsComponent) string { return formatTimeout(s.run.TimeoutSeconds) },
+		step: func(s *SettingsComponent, dir int) {
+			s.run.TimeoutSeconds = stepValue(timeoutSteps, s.run.TimeoutSeconds, dir)
+		},
+	},
+	{
+		name:   "Concurrency",
+		format: func(s *SettingsComponent) string { return fmt.Sprintf("%d models at once", s.concurrency) },
+		step: func(s *SettingsComponent, dir int) {
+			s.concurrency = stepValue(concurrencySteps, s.concurrency, dir)
+		},
+	},
+	{
+		name:   "Warm-up",
+		format: func(s *SettingsComponent) string { return fmt.Sprintf("%d calls", s.run.Warmup) },
+		step: func(s *SettingsComponent, dir int) {
+			s.run.Warmup = stepValue(warmupSteps, s.run.Warmup, dir)
+		},
+	},
+}
+
+func NewSettingsComponent(width int, run config.Run, concurrency int) *SettingsComponent {
+	return &SettingsComponent{
+		width:       width,
+		run:         run,
+		concurrency: concurrency,
+	}
+}
+
+func (s *SettingsComponent) Init() tea.Cmd {
+	return nil
+}
+
+// Update selects a setting on `j`/`k` and changes it on `h`/`l`.
+func (s *SettingsComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return s, nil
+	}
+
+	switch key.String() {
+	case "j", "down":
+		s.cursor = min(s.cursor+1, len(settings)-1)
+
+	case "k", "up":
+		s.cursor = max(s.cursor-1, 0)
+
+	case "l", "right":
+		settings[s.cursor].step(s, 1)
+
+	case "h", "left":
+		settings[s.cursor].step(s, -1)
+	}
+
+	return s, nil
+}
+
+// SetWidth sets inner width of the modal.
+func (s *SettingsComponent) SetWidth(width int) {
+	s.width = width
+}
+
+// Visible reports whether settings are open.
+func (s *SettingsComponent) Visible() bool {
+	return s.visible
+}
+
+func (s *SettingsComponent) Open() {
+	s.visible = true
+}
+
+func (s *SettingsComponent) Close() {
+	s.visible = false
+}
+
+// Run returns current run settings.
+func (s *SettingsComponent) Run() config.Run {
+	return s.run
+}
+
+// Concurrency returns current number of models measured at once.
+func (s *SettingsComponent) Concurrency() int {
+	return s.concurrency
+}
+
+func (s *SettingsComponent) View() string {
+	container := lg.NewStyle().
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("241")).
+		Width(s.width)
+
+	header := lg.NewStyle().
+		Bold(true).
+		PaddingLeft(1).
+		Render("Settings")
+
+	separator := lg.NewStyle().
+		Foreground(lg.Color("240")).
+		Render(strings.Repeat("─", s.width))
+
+	label := lg.NewStyle().
+		Width(16).
+		PaddingLeft(1).
+		Foreground(lg.Color("241"))
+	value := lg.NewStyle()
+	selected := lg.NewStyle().
+		Foreground(lg.Color("#fff")).
+		Background(lg.Color("#2b5ccc")).
+		Bold(true)
+
+	var rows []string
+	for i, st := range settings {
+		v := value.Render(st.format(s))
+		if i == s.cursor {
+			v = selected.Render("‹ " + st.format(s) + " ›")
+		}
+		rows = append(rows, lg.JoinHorizontal(lg.Top, label.Render(st.name), v))
+	}
+
+	help := lg.NewStyle().
+		Foreground(lg.Color("241")).
+		PaddingLeft(1).
+		Render("j/k: select | h/l: change | w: save to config | o/esc: close")
+
+	return container.Render(lg.JoinVertical(
+		lg.Top,
+		header,
+		separator,
+		strings.Join(rows, "\n"),
+		separator,
+		help))
+}
+
+// stepValue returns the nearest step after current value in a given
+// direction, current value if there is none.
+func stepValue(steps []int, current int, dir int) int {
+	if dir > 0 {
+		for _, v := range steps {
+			if v > current {
+				return v
+			}
+		}
+		return current
+	}
+
+	for _, v := range slices.Backward(steps) {
+		if v < current {
+			return v
+		}
+	}
+	return current
+}
+
+// formatRunSettings summarizes run settings in a single line.
+func formatRunSettings(run config.Run, concurrency int) string {
+	warmup := "no warm-up"
+	if run.Warmup > 0 {
+		warmup = fmt.Sprintf("%d warm-up", run.Warmup)
+	}
+
+	return fmt.Sprintf("%s, %s prompts, timeout %s, %d at once, %s",
+		formatSampleSize(run.SampleSize), formatPromptMode(run.PromptMode), formatTimeout(run.TimeoutSeconds), concurrency, warmup)
+}
+
+func formatSampleSize(n int) string {
+	if n <= 0 {
+		return "1 sample per prompt"
+	}
+	return fmt.Sprintf("%d samples", n)
+}
+
+func formatPromptMode(mode evaluator.PromptMode) string {
+	if mode == evaluator.PromptModeAuto {
+		return "auto"
+	}
+	return string(mode)
+}
+
+func formatTimeout(seconds int) string {
+	if seconds <= 0 {
+		return "none"
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sortableColumns are columns rows can be sorted by, in order of cycling.
+var sortableColumns = []int{
+	columnLatency,
+	columnP95,
+	columnTool,
+	columnTokens,
+	columnSuccess,
+	columnErrors,
+	columnName,
+	columnProvider,
+	columnVendor,
+	columnFamily,
+}
+
+// numericColumns are sorted by value, other columns alphabetically.
+var numericColumns = []int{columnLatency, columnP95, columnTool, columnTokens, columnSuccess, columnErrors}
+
+// ToggleSortOrder flips sorting order of a current sort column.
+func (s *TableComponent) ToggleSortOrder() {
+	if s.sorted {
+		s.sortAsc = !s.sortAsc
+	} else {
+		s.sortAsc = true
+	}
+	s.sortRows()
+}
+
+// CycleSortColumn sorts rows by the next sortable column in ascending order.
+func (s *TableComponent) CycleSortColumn() {
+	next := 0
+	if i := slices.Index(sortableColumns, s.sortColumn); i >= 0 && s.sorted {
+		next = (i + 1) % len(sortableColumns)
+	}
+
+	s.sortColumn = sortableColumns[next]
+	s.sortAsc = true
+	s.sortRows()
+}
+
+// SortByColumn sorts rows by a given column in ascending order, or flips
+// the order if rows are already sorted by it. Columns which are not
+// sortable are ignored.
+func (s *TableComponent) SortByColumn(column int) {
+	if !slices.Contains(sortableColumns, column) {
+		return
+	}
+
+	if s.sorted && s.sortColumn == column {
+		s.sortAsc = !s.sortAsc
+	} else {
+		s.sortColumn = column
+		s.sortAsc = true
+	}
+	s.sortRows()
+}
+
+// HeaderColumnAt returns a column which header is at given
+// coordinates relative to the table. Table is drawn in a bordered box,
+// so headers are on the second line and each cell is padded by one space
+// on both sides.
+func (s *TableComponent) HeaderColumnAt(x, y int) (int, bool) {
+	if y != 1 {
+		return 0, false
+	}
+
+	left := 1
+	for i, c := range s.table.Columns() {
+		if c.Width <= 0 {
+			continue
+		}
+
+		right := left + c.Width + 2
+		if x >= left && x < right {
+			return i, true
+		}
+		left = right
+	}
+
+	return 0, false
+}
+
+// sortRows sorts all rows by a sort column keeping the order of rows with
+// equal values. Cells without a value, such as `err`, `queued` or empty
+// cells, always go last regardless of the order.
+func (s *TableComponent) sortRows() {
+	s.sorted = true
+	numeric := slices.Contains(numericColumns, s.sortColumn)
+
+	sort.SliceStable(s.rows, func(i, j int) bool {
+		a, b := s.rows[i][s.sortColumn], s.rows[j][s.sortColumn]
+
+		if numeric {
+			x, okX := cellNumber(a)
+			y, okY := cellNumber(b)
+			if !okX || !okY {
+				return okX && !okY
+			}
+			if s.sortAsc {
+				return x < y
+			}
+			return x > y
+		}
+
+		x, y := strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b))
+		if x == "" || y == "" {
+			return x != "" && y == ""
+		}
+		if s.sortAsc {
+			return x < y
+		}
+		return x > y
+	})
+
+	s.updateHeader()
+	s.refreshRows()
+}
+
+// cellNumber parses a numeric cell, such as `412`, `45.2` or `5%`.
+// Returns false if a cell has no value.
+func cellNumber(cell string) (float64, bool) {
+	v, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(cell), "%"), 64)
+	return v, err == nil
+}
+
+// updateHeader sets column widths and marks a sort column and its order
+// in the header.
+func (s *TableComponent) updateHeader() {
+	columns := slices.Clone(tableColumns)
+	for i, w := range s.widths {
+		columns[i].Width = w
+	}
+
+	if s.sorted {
+		indicator := " ▼"
+		if s.sortAsc {
+			indicator = " ▲"
+		}
+		columns[s.sortColumn].Title += indicator
+	}
+
+	s.table.SetColumns(columns)
+}
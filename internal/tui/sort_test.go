@@ -0,0 +1,70 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+)
+
+// newSortTable returns a table with rows which have given cells in a column.
+func newSortTable(column int, cells ...string) *TableComponent {
+	var rows []table.Row
+	for _, c := range cells {
+		row := make(table.Row, len(tableColumns))
+		row[column] = c
+		rows = append(rows, row)
+	}
+
+	return &TableComponent{
+		table: table.New(table.WithColumns(tableColumns)),
+		rows:  rows,
+	}
+}
+
+func column(rows []table.Row, column int) []string {
+	var res []string
+	for _, r := range rows {
+		res = append(res, r[column])
+	}
+	return res
+}
+
+func TestSortByColumn(t *testing.T) {
+	cases := []struct {
+		name   string
+		column int
+		cells  []string
+		asc    []string
+		desc   []string
+	}{
+		{"latency", columnLatency, []string{"412", "err", "95", ""}, []string{"95", "412", "err", ""}, []string{"412", "95", "err", ""}},
+		{"success", columnSuccess, []string{"100%", "", "40%", "95%"}, []string{"40%", "95%", "100%", ""}, []string{"100%", "95%", "40%", ""}},
+		{"errors", columnErrors, []string{"5%", "0%", "", "60%"}, []string{"0%", "5%", "60%", ""}, []string{"60%", "5%", "0%", ""}},
+		{"name", columnName, []string{"Mixtral", "claude", "", "GPT"}, []string{"claude", "GPT", "Mixtral", ""}, []string{"Mixtral", "GPT", "claude", ""}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			s := newSortTable(c.column, c.cells...)
+
+			s.SortByColumn(c.column)
+			if got := column(s.rows, c.column); !slices.Equal(got, c.asc) {
+				t.Errorf("expected ascending %q, got %q", c.asc, got)
+			}
+
+			s.SortByColumn(c.column)
+			if got := column(s.rows, c.column); !slices.Equal(got, c.desc) {
+				t.Errorf("expected descending %q, got %q", c.desc, got)
+			}
+		})
+	}
+}
+
+func TestEveryMeasuredColumnIsSortable(t *testing.T) {
+	for c := columnName; c < columnTrend; c++ {
+		if !slices.Contains(sortableColumns, c) {
+			t.Errorf("column %s is not sortable", tableColumns[c].Title)
+		}
+	}
+}
@@ -1,31 +1,109 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	lg "github.com/charmbracelet/lipgloss"
+	"github.com/pvlbzn/latai/internal/config"
 	"github.com/pvlbzn/latai/internal/evaluator"
+	"github.com/pvlbzn/latai/internal/pricing"
 	"github.com/pvlbzn/latai/internal/prompt"
 	"github.com/pvlbzn/latai/internal/provider"
-	"math"
+	"github.com/pvlbzn/latai/internal/scheduler"
+	"github.com/pvlbzn/latai/internal/stats"
+	"slices"
 	"sort"
 	"strconv"
+	"time"
 )
 
+// Column indices of a table row.
+const (
+	columnMark = iota
+	columnID
+	columnName
+	columnProvider
+	columnVendor
+	columnFamily
+	columnLatency
+	columnP95
+	columnTool
+	columnTokens
+	columnSuccess
+	columnErrors
+	columnTrend
+)
+
+// tableColumns are columns of the table without sort indicator.
+var tableColumns = []table.Column{
+	{Title: "*", Width: 1},
+	{Title: "ID", Width: 2},
+	{Title: "Name", Width: 32},
+	{Title: "Provider", Width: 8},
+	{Title: "Vendor", Width: 10},
+	{Title: "Family", Width: 9},
+	{Title: "Latency", Width: 11},
+	{Title: "p95", Width: 6},
+	{Title: "Tool", Width: 6},
+	{Title: "Tok/s", Width: 7},
+	{Title: "OK", Width: 4},
+	{Title: "Err", Width: 5},
+	{Title: "Trend", Width: 8},
+}
+
 type TableComponent struct {
-	// Table data.
+	// Table data. Rows hold all rows, while table shows only
+	// rows which match filter.
 	table table.Model
 	rows  []table.Row
 
+	// Live filter of rows. Matches hold row IDs matching filter,
+	// nil when filter is empty.
+	filter      textinput.Model
+	filtering   bool
+	filterMatch map[int]bool
+
+	// Row IDs marked to run as a subset.
+	marked map[int]bool
+
+	// Scheduler of measurements and channel of events, such as state
+	// changes and progress, sent by running measurements.
+	scheduler *scheduler.Scheduler
+	events    chan tea.Msg
+
+	// Cancel functions of in-flight measurements by row ID.
+	inFlight map[int]context.CancelFunc
+
+	// Settings of measurements, applied to measurements started after
+	// they change.
+	run config.Run
+
 	// Initialized providers and their models.
 	providers []*tuiProvider
 
-	// Sorting order.
-	sortAsc bool
+	// Outer width of the table and widths of its columns fitted into it,
+	// zero width hides a column. Nil widths are default widths.
+	width  int
+	widths []int
+
+	// Sorting column and order, rows are not sorted until user
+	// asks to.
+	sortColumn int
+	sortAsc    bool
+	sorted     bool
 
 	cursor int
 
+	// Model prices, observed average output tokens of each row
+	// for cost estimates, and cost of all runs of a session.
+	prices       pricing.Table
+	outputTokens map[int]int
+	sessionCost  float64
+
 	logger *LoggerComponent
 }
 
@@ -34,35 +112,41 @@ type tuiProvider struct {
 	models   []*provider.Model
 }
 
-func NewTableComponent(providers []provider.Provider, logger *LoggerComponent) *TableComponent {
+func NewTableComponent(providers []provider.Provider, prices pricing.Table, sched *scheduler.Scheduler, logger *LoggerComponent) *TableComponent {
 	var tuiProviders []*tuiProvider
 	for _, p := range providers {
 		models := p.GetLLMModels("")
 		tuiProviders = append(tuiProviders, &tuiProvider{
-			provider: p,
+			provider: sched.Wrap(p),
 			models:   models,
 		})
 	}
 
 	t, r := makeTableModel(tuiProviders)
 
+	f := textinput.New()
+	f.Prompt = "/ "
+	f.Placeholder = "name, provider, vendor, family or ID"
+	f.CharLimit = 64
+
 	return &TableComponent{
-		table:     t,
-		rows:      r,
-		providers: tuiProviders,
-		logger:    logger,
+		table:        t,
+		rows:         r,
+		filter:       f,
+		marked:       make(map[int]bool),
+		scheduler:    sched,
+		events:       make(chan tea.Msg, 64),
+		inFlight:     make(map[int]context.CancelFunc),
+		providers:    tuiProviders,
+		sortColumn:   columnLatency,
+		prices:       prices,
+		outputTokens: make(map[int]int),
+		logger:       logger,
 	}
 }
 
 func makeTableModel(tuiProviders []*tuiProvider) (table.Model, []table.Row) {
 	height := 28
-	columns := []table.Column{
-		{Title: "ID", Width: 2},
-		{Title: "Name", Width: 32},
-		{Title: "Provider", Width: 8},
-		{Title: "Vendor", Width: 11},
-		{Title: "Latency", Width: 7},
-	}
 
 	// Get sequential list of all models.
 	var models []*provider.Model
@@ -73,11 +157,13 @@ func makeTableModel(tuiProviders []*tuiProvider) (table.Model, []table.Row) {
 	// Create rows
 	var rows []table.Row
 	for i, m := range models {
-		rows = append(rows, table.Row{strconv.Itoa(i), m.Name, string(m.Provider), string(m.Vendor), " "})
+		rows = append(rows, table.Row{
+			" ", strconv.Itoa(i), m.Name, string(m.Provider), string(m.Vendor), string(m.Family),
+			" ", " ", " ", " ", " ", " ", " "})
 	}
 
 	t := table.New(
-		table.WithColumns(columns),
+		table.WithColumns(tableColumns),
 		table.WithRows(rows),
 		table.WithFocused(true),
 		table.WithHeight(height))
@@ -122,7 +208,7 @@ func (s *TableComponent) getModelByRowID(rowID int) (provider.Provider, *provide
 		}
 	}
 
-	if rowID > len(index) {
+	if rowID < 0 || rowID >= len(index) {
 		return nil, nil, ErrIndexNotFound
 	}
 
@@ -152,15 +238,13 @@ func (s *TableComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			"%s latency %s ms", msg.name, msg.latency))
 
 		// Update latency and whole table.
-		s.rows[msg.id][4] = msg.latency
-		s.table.SetRows(s.rows)
+		s.UpdateLatency(msg)
 
 		return s, nil
 
 	case latencyErrMsg:
-		s.logger.Push(fmt.Sprintf("Error measuring %s model: %s", msg.name, msg.err))
-		s.rows[msg.id][4] = "err"
-		s.table.SetRows(s.rows)
+		s.logger.Error(fmt.Sprintf("Error measuring %s model: %s", msg.name, msg.err))
+		s.SetLatencyError(msg.id, msg.label)
 	}
 
 	var tableCmd tea.Cmd
@@ -178,7 +262,7 @@ func (s *TableComponent) MoveCursorUp() bool {
 }
 
 func (s *TableComponent) MoveCursorDown() bool {
-	if s.cursor < len(s.rows)-1 {
+	if s.cursor < len(s.table.Rows())-1 {
 		s.cursor++
 		return true
 	}
@@ -192,22 +276,64 @@ func (s *TableComponent) View() string {
 	)
 }
 
-// makeTableView returns a view of table of models and help string.
+// makeTableView returns a view of table of models, filter and help string.
 func (s *TableComponent) makeTableView() string {
+	view := s.table.View()
+	if filter := s.makeFilterView(); filter != "" {
+		view += "\n" + filter
+	}
+
 	return lg.NewStyle().
 		BorderStyle(lg.NormalBorder()).
 		BorderForeground(lg.Color("241")).
-		Render(s.table.View() + "\n" + s.makeHelpView())
+		Render(view + "\n" + s.makeHelpView())
+}
+
+// makeFilterView returns a view of filter input while filtering, a summary
+// of applied filter, or empty string if there is no filter.
+func (s *TableComponent) makeFilterView() string {
+	style := lg.NewStyle().PaddingLeft(1)
+
+	if s.filtering {
+		return style.Render(s.filter.View())
+	}
+
+	if s.filterMatch != nil {
+		return style.
+			Foreground(lg.Color("214")).
+			Render(fmt.Sprintf("filter: %s (%d of %d) | esc: clear", s.filter.Value(), len(s.table.Rows()), len(s.rows)))
+	}
+
+	return ""
 }
 
 // makeHelpView returns a view of a single help string.
 func (s *TableComponent) makeHelpView() string {
-
-	return lg.NewStyle().
+	style := lg.NewStyle().
 		Foreground(lg.Color("241")).
 		PaddingTop(1).
-		PaddingLeft(1).
-		Render(fmt.Sprintf("enter: run | A: run all | J/K: up/down | s: sort | q: quit"))
+		PaddingLeft(1)
+
+	// Wrap help within the table once terminal size is known.
+	if s.width > 0 {
+		style = style.Width(s.width - 2)
+	}
+
+	return style.Render(fmt.Sprintf(
+		"enter: run | A: run all | space: mark | m: run marked (%d) | x/X: cancel/all\n"+
+			"c: compare marked | /: filter | v: view | J/K: up/down | s/S: sort order/column | q: quit | session %s\n"+
+			"o: settings (%s)",
+		len(s.marked), pricing.Format(s.sessionCost), formatRunSettings(s.run, s.scheduler.Concurrency())))
+}
+
+// SetRun sets settings of measurements started afterwards.
+func (s *TableComponent) SetRun(run config.Run) {
+	s.run = run
+}
+
+// SetConcurrency sets number of models measured at once.
+func (s *TableComponent) SetConcurrency(n int) {
+	s.scheduler.SetConcurrency(n)
 }
 
 func (s *TableComponent) ToggleFocus() {
@@ -218,24 +344,215 @@ func (s *TableComponent) ToggleFocus() {
 	}
 }
 
-func (s *TableComponent) SortByLatency() tea.Cmd {
-	return sortRowsCmd(s)
-}
-
 func (s *TableComponent) ScrollTop() {
 	s.cursor = 0
 	s.table.SetCursor(0)
 }
 
 func (s *TableComponent) ScrollBottom() {
-	s.cursor = len(s.rows) - 1
-	s.table.SetCursor(len(s.rows) - 1)
+	s.cursor = len(s.table.Rows()) - 1
+	s.table.SetCursor(len(s.table.Rows()) - 1)
+}
+
+// StartFilter focuses filter input.
+func (s *TableComponent) StartFilter() tea.Cmd {
+	s.filtering = true
+	return s.filter.Focus()
+}
+
+// Filtering reports whether filter input is focused.
+func (s *TableComponent) Filtering() bool {
+	return s.filtering
+}
+
+// HasFilter reports whether rows are narrowed by a filter.
+func (s *TableComponent) HasFilter() bool {
+	return s.filterMatch != nil
+}
+
+// UpdateFilter handles keys while filter input is focused, narrowing rows
+// live as filter changes. Enter keeps the filter, esc clears it.
+func (s *TableComponent) UpdateFilter(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "enter":
+		s.filtering = false
+		s.filter.Blur()
+		return nil
+
+	case "esc":
+		s.ClearFilter()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	s.filter, cmd = s.filter.Update(msg)
+	s.applyFilter(s.filter.Value())
+
+	return cmd
+}
+
+// ClearFilter removes filter and shows all rows.
+func (s *TableComponent) ClearFilter() {
+	s.filtering = false
+	s.filter.Blur()
+	s.filter.SetValue("")
+	s.applyFilter("")
+}
+
+// applyFilter narrows rows down to models matching query. Matching is
+// delegated to providers, see provider.Provider.GetLLMModels.
+func (s *TableComponent) applyFilter(query string) {
+	if query == "" {
+		s.filterMatch = nil
+	} else {
+		s.filterMatch = make(map[int]bool)
+
+		id := 0
+		for _, p := range s.providers {
+			matched := make(map[string]bool)
+			for _, m := range p.provider.GetLLMModels(query) {
+				matched[m.ID] = true
+			}
+
+			for _, m := range p.models {
+				if matched[m.ID] {
+					s.filterMatch[id] = true
+				}
+				id++
+			}
+		}
+	}
+
+	s.refreshRows()
+	s.ScrollTop()
+}
+
+// refreshRows sets rows matching filter into the table.
+func (s *TableComponent) refreshRows() {
+	if s.filterMatch == nil {
+		s.table.SetRows(s.rows)
+		return
+	}
+
+	visible := make([]table.Row, 0, len(s.filterMatch))
+	for _, r := range s.rows {
+		id, _ := strconv.Atoi(r[columnID])
+		if s.filterMatch[id] {
+			visible = append(visible, r)
+		}
+	}
+	s.table.SetRows(visible)
+}
+
+// rowByID returns a row of a model with a given row ID. Position of a row
+// differs from its ID once rows are sorted.
+func (s *TableComponent) rowByID(id int) table.Row {
+	key := strconv.Itoa(id)
+	for _, r := range s.rows {
+		if r[columnID] == key {
+			return r
+		}
+	}
+
+	return nil
+}
+
+// VisibleRowIDs returns IDs of rows matching filter.
+func (s *TableComponent) VisibleRowIDs() []int {
+	var ids []int
+	for _, r := range s.table.Rows() {
+		id, _ := strconv.Atoi(r[columnID])
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// selectedRowID returns ID of a selected row.
+func (s *TableComponent) selectedRowID() (int, error) {
+	row := s.table.SelectedRow()
+	if row == nil {
+		return 0, ErrIndexNotFound
+	}
+
+	return strconv.Atoi(row[columnID])
+}
+
+// ToggleMark marks or unmarks a selected row.
+func (s *TableComponent) ToggleMark() error {
+	id, err := s.selectedRowID()
+	if err != nil {
+		return err
+	}
+
+	s.setMark(id, !s.marked[id])
+	s.refreshRows()
+
+	return nil
+}
+
+func (s *TableComponent) setMark(id int, marked bool) {
+	row := s.rowByID(id)
+	if marked {
+		s.marked[id] = true
+		row[columnMark] = "*"
+	} else {
+		delete(s.marked, id)
+		row[columnMark] = " "
+	}
+}
+
+// MarkModels marks rows of referenced models. References to models
+// which are not loaded are ignored.
+func (s *TableComponent) MarkModels(refs []config.ModelRef) {
+	for id, row := range s.GetRows() {
+		for _, ref := range refs {
+			if row.model.Provider == ref.Provider && row.model.ID == ref.ID {
+				s.setMark(id, true)
+			}
+		}
+	}
+	s.refreshRows()
+}
+
+// MarkedModels returns references to marked models in row order.
+func (s *TableComponent) MarkedModels() []config.ModelRef {
+	var refs []config.ModelRef
+	for id, row := range s.GetRows() {
+		if s.marked[id] {
+			refs = append(refs, config.ModelRef{Provider: row.model.Provider, ID: row.model.ID})
+		}
+	}
+
+	return refs
+}
+
+// HasModel reports whether referenced model is loaded.
+func (s *TableComponent) HasModel(ref config.ModelRef) bool {
+	for _, row := range s.GetRows() {
+		if row.model.Provider == ref.Provider && row.model.ID == ref.ID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MarkedRowIDs returns IDs of marked rows regardless of filter.
+func (s *TableComponent) MarkedRowIDs() []int {
+	ids := make([]int, 0, len(s.marked))
+	for id := range s.marked {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	return ids
 }
 
 func (s *TableComponent) GetSelectedRow() (int, *provider.Model, error) {
-	selectedRowID, err := strconv.Atoi(s.table.SelectedRow()[0])
+	selectedRowID, err := s.selectedRowID()
 	if err != nil {
-		s.logger.Push("Error selecting row ID: " + err.Error())
+		return 0, nil, err
 	}
 	_, m, err := s.getModelByRowID(selectedRowID)
 
@@ -243,54 +560,327 @@ func (s *TableComponent) GetSelectedRow() (int, *provider.Model, error) {
 }
 
 func (s *TableComponent) MeasureRowLatency() tea.Cmd {
-	selectedRowID, err := strconv.Atoi(s.table.SelectedRow()[0])
+	selectedRowID, err := s.selectedRowID()
 	if err != nil {
-		s.logger.Push("Error selecting row ID: " + err.Error())
+		s.logger.Error("Error selecting row ID: " + err.Error())
+		return nil
+	}
+
+	row := s.rowByID(selectedRowID)
+	if _, ok := s.inFlight[selectedRowID]; ok {
+		s.logger.Warn(fmt.Sprintf("%s is already being measured", row[columnName]))
+		return nil
 	}
 
 	// Log event.
-	s.logger.Push(fmt.Sprintf("Measuring %s latency", s.rows[selectedRowID][1]))
+	s.logger.Push(fmt.Sprintf("Measuring %s latency", row[columnName]))
 
 	// Update fields.
-	s.rows[selectedRowID][4] = "..."
-	s.table.SetRows(s.rows)
+	row[columnLatency] = rowStateQueued
+	s.refreshRows()
 
 	// Start the concurrent task and return a command
-	return fetchModelLatencyCmd(s, selectedRowID)
+	return fetchModelLatencyCmd(s.start(selectedRowID), s, selectedRowID)
 }
 
-func (s *TableComponent) MeasureAllRowLatency() tea.Cmd {
-	s.logger.Push(fmt.Sprintf("Running %d parallel benchmarks", s.countAllModels()))
+// MeasureRowsLatency measures rows with given IDs, skipping rows which
+// are already being measured.
+func (s *TableComponent) MeasureRowsLatency(ids []int) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, id := range ids {
+		if _, ok := s.inFlight[id]; ok {
+			continue
+		}
 
-	for _, r := range s.rows {
-		r[4] = "..."
+		s.rowByID(id)[columnLatency] = rowStateQueued
+		cmds = append(cmds, fetchModelLatencyCmd(s.start(id), s, id))
 	}
-	s.table.SetRows(s.rows)
+	s.refreshRows()
 
-	return fetchAllModelLatencyCmd(s)
+	s.logger.Push(fmt.Sprintf("Running %d parallel benchmarks", len(cmds)))
+
+	// Scheduler limits how many of them run at once.
+	return tea.Batch(cmds...)
+}
+
+// start registers an in-flight measurement of a row and returns its context.
+func (s *TableComponent) start(id int) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.inFlight[id] = cancel
+
+	return ctx
+}
+
+// finish forgets an in-flight measurement of a row once it's done.
+func (s *TableComponent) finish(id int) {
+	if cancel, ok := s.inFlight[id]; ok {
+		cancel()
+		delete(s.inFlight, id)
+	}
+}
+
+// Measuring reports whether a row is being measured.
+func (s *TableComponent) Measuring(id int) bool {
+	_, ok := s.inFlight[id]
+	return ok
+}
+
+// CancelSelectedRow cancels measurement of a selected row. Returns false
+// if the row is not being measured.
+func (s *TableComponent) CancelSelectedRow() (bool, error) {
+	id, err := s.selectedRowID()
+	if err != nil {
+		return false, err
+	}
+
+	cancel, ok := s.inFlight[id]
+	if ok {
+		cancel()
+	}
+
+	return ok, nil
 }
 
-func (s *TableComponent) UpdateLatency(id int, latency string) {
-	s.rows[id][4] = latency
-	s.table.SetRows(s.rows)
+// CancelAll cancels all in-flight measurements and returns their count.
+func (s *TableComponent) CancelAll() int {
+	for _, cancel := range s.inFlight {
+		cancel()
+	}
+
+	return len(s.inFlight)
 }
 
-func (s *TableComponent) SetLatencyError(id int) {
-	s.rows[id][4] = "err"
-	s.table.SetRows(s.rows)
+// UpdateLatency shows result of a finished measurement.
+func (s *TableComponent) UpdateLatency(msg latencyUpdatedMsg) {
+	s.finish(msg.id)
+
+	row := s.rowByID(msg.id)
+	row[columnLatency] = msg.latency
+	row[columnP95] = fmt.Sprintf("%d", stats.Percentile(msg.samples, 95).Milliseconds())
+	row[columnTool] = " "
+	if len(msg.toolLatency) > 0 {
+		row[columnTool] = fmt.Sprintf("%d", stats.Median(msg.toolLatency).Milliseconds())
+	}
+	row[columnTokens] = " "
+	if tps, ok := newCompareResult(msg).tokensPerSecond(); ok {
+		row[columnTokens] = fmt.Sprintf("%.1f", tps)
+	}
+	row[columnSuccess] = formatRate(msg.success)
+	row[columnErrors] = formatRate(msg.errRate)
+	row[columnTrend] = sparkline(msg.samples, trendWidth)
+	s.refreshRows()
 }
 
-func fetchModelLatencyCmd(t *TableComponent, modelRowID int) tea.Cmd {
+// trendWidth is a width of sparkline in trend column.
+const trendWidth = 8
+
+// SetTrend shows sparkline of samples measured so far by a running
+// measurement.
+func (s *TableComponent) SetTrend(id int, samples []time.Duration) {
+	if !s.Measuring(id) {
+		return
+	}
+
+	s.rowByID(id)[columnTrend] = sparkline(samples, trendWidth)
+	s.refreshRows()
+}
+
+// formatRate formats share of samples as a percentage.
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
+
+// AddCost records cost of a measurement of a row and its average output
+// tokens, which are used for further cost estimates of the row.
+func (s *TableComponent) AddCost(id int, cost float64, avgOutputTokens int) {
+	s.sessionCost += cost
+	if avgOutputTokens > 0 {
+		s.outputTokens[id] = avgOutputTokens
+	}
+}
+
+// runPrompts loads prompts for given run settings, user-defined prompts
+// are sampled first. Tools, structured and vision modes fall back to
+// default prompts of their kind if none are found.
+func runPrompts(run config.Run) ([]*prompt.Prompt, error) {
+	prompts, err := prompt.GetSampledPrompts(run.PromptSampling())
+	if err != nil {
+		return nil, err
+	}
+
+	switch run.PromptMode {
+	case evaluator.PromptModeTools:
+		return prompt.ToolPrompts(prompts), nil
+	case evaluator.PromptModeStructured:
+		return prompt.StructuredPrompts(prompts), nil
+	case evaluator.PromptModeVision:
+		return prompt.VisionPrompts(prompts), nil
+	default:
+		return prompts, nil
+	}
+}
+
+// runSampleSize returns number of samples of a run over a given number of
+// prompts. Structured and vision modes run each prompt twice unless set
+// otherwise.
+func runSampleSize(run config.Run, prompts int) int {
+	switch {
+	case run.SampleSize > 0:
+		return run.SampleSize
+	case run.PromptMode == evaluator.PromptModeStructured, run.PromptMode == evaluator.PromptModeVision:
+		return 2 * prompts
+	default:
+		return prompts
+	}
+}
+
+// EstimateRowCost estimates cost of measuring rows with given IDs with
+// current prompts and settings. Returns estimated cost, number of calls,
+// and number of models without price which are not included in the estimate.
+func (s *TableComponent) EstimateRowCost(ids []int) (float64, int, int, error) {
+	prompts, err := runPrompts(s.run)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(prompts) == 0 {
+		return 0, 0, 0, nil
+	}
+
+	// Each model makes warm-up calls and a call per sample. Cache mode
+	// skips warm-up and sends a shared prefix with each prompt, which is
+	// priced in full even though cached reads are cheaper.
+	cache := s.run.PromptMode == evaluator.PromptModeCache
+	calls := runSampleSize(s.run, len(prompts))
+	if !cache {
+		calls += s.run.Warmup
+	}
+
+	var promptTokens int
+	for _, p := range prompts {
+		if cache {
+			p = p.WithCachedPrefix(prompt.CachePrefix(""))
+		}
+		promptTokens += pricing.EstimatePromptTokens(p)
+	}
+	inputTokens := promptTokens * calls / len(prompts)
+
+	var cost float64
+	var unpriced int
+	for _, id := range ids {
+		_, m, err := s.getModelByRowID(id)
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		price, ok := s.prices.Lookup(m)
+		if !ok {
+			unpriced++
+			continue
+		}
+
+		outputTokens, ok := s.outputTokens[id]
+		if !ok {
+			outputTokens = pricing.DefaultOutputTokens
+		}
+		cost += price.Cost(inputTokens, outputTokens*calls)
+	}
+
+	return cost, calls * len(ids), unpriced, nil
+}
+
+// SetRowState shows state or progress of a measurement in latency cell
+// unless the measurement already finished.
+func (s *TableComponent) SetRowState(id int, state string) {
+	if !s.Measuring(id) {
+		return
+	}
+
+	s.rowByID(id)[columnLatency] = state
+	s.refreshRows()
+}
+
+// ListenEvents waits for the next event of running measurements.
+func (s *TableComponent) ListenEvents() tea.Cmd {
+	return func() tea.Msg {
+		return <-s.events
+	}
+}
+
+// SetLatencyError marks a row which measurement failed with a label of
+// the error class.
+func (s *TableComponent) SetLatencyError(id int, label string) {
+	s.finish(id)
+	s.rowByID(id)[columnLatency] = label
+	s.refreshRows()
+}
+
+// errorLabels are labels of error classes short enough for latency cell.
+var errorLabels = map[error]string{
+	provider.ErrAuth:              "auth",
+	provider.ErrAccessDenied:      "no access",
+	provider.ErrThrottled:         "throttled",
+	provider.ErrTimeout:           "timeout",
+	provider.ErrModelNotFound:     "not found",
+	provider.ErrInvalidRequest:    "bad request",
+	provider.ErrServer:            "server err",
+	provider.ErrMalformedResponse: "bad resp",
+}
+
+// errorLabel returns a label of error class, `err` if error has no class.
+func errorLabel(err error) string {
+	if errors.Is(err, evaluator.ErrTimeout) {
+		return errorLabels[provider.ErrTimeout]
+	}
+	if label, ok := errorLabels[provider.ClassOf(err)]; ok {
+		return label
+	}
+	return "err"
+}
+
+// newLatencyErrMsg reports a failed measurement of a row.
+func newLatencyErrMsg(id int, name string, err error) latencyErrMsg {
+	return latencyErrMsg{id: id, name: name, label: errorLabel(err), err: err.Error()}
+}
+
+// SetCancelled marks a row which measurement was cancelled.
+func (s *TableComponent) SetCancelled(id int) {
+	s.finish(id)
+	s.rowByID(id)[columnLatency] = "cancel"
+	s.refreshRows()
+}
+
+// SetSkipped shows that a model was not measured, e.g. as it doesn't
+// accept images of prompts.
+func (s *TableComponent) SetSkipped(id int) {
+	s.finish(id)
+	s.rowByID(id)[columnLatency] = "skipped"
+	s.refreshRows()
+}
+
+// fetchModelLatencyCmd measures a row. Cancelling context aborts
+// the measurement at any stage, including waiting in the queue.
+func fetchModelLatencyCmd(ctx context.Context, t *TableComponent, modelRowID int) tea.Cmd {
+	// Settings may change while the measurement waits in the queue.
+	run := t.run
+
 	return func() tea.Msg {
 		// Process the selected row (e.g., calculate latency or fetch new data)
 		p, m, err := t.getModelByRowID(modelRowID)
 		if err != nil {
-			return latencyErrMsg{modelRowID, m.Name, err.Error()}
+			return newLatencyErrMsg(modelRowID, strconv.Itoa(modelRowID), err)
 		}
 
-		prompts, err := prompt.GetPrompts()
+		// Wait for a free slot, row stays queued meanwhile.
+		release, err := t.scheduler.Acquire(ctx, m.Provider)
 		if err != nil {
-			return latencyErrMsg{modelRowID, m.Name, err.Error()}
+			return latencyCancelledMsg{modelRowID, m.Name}
+		}
+		defer release()
+		t.events <- rowStateMsg{modelRowID, rowStateRunning}
+
+		prompts, err := runPrompts(run)
+		if err != nil {
+			return newLatencyErrMsg(modelRowID, m.Name, err)
 		}
 
 		if len(prompts) != 0 {
@@ -298,70 +888,145 @@ func fetchModelLatencyCmd(t *TableComponent, modelRowID int) tea.Cmd {
 			t.logger.Push(fmt.Sprintf("Sampling with %d %s prompts", len(prompts), prompts[0].Type))
 		}
 
-		eval := evaluator.NewEvaluator(p, m, prompts...)
-		res, err := eval.Evaluate()
+		// Report progress after each sample, the table shows it until
+		// the final result arrives.
+		var latency []time.Duration
+		retries := 0
+		eval := evaluator.NewEvaluator(p, m, prompts...).
+			WithPromptMode(run.PromptMode).
+			WithTimeout(run.Timeout()).
+			WithWarmup(run.Warmup).
+			WithProgress(func(pr evaluator.Progress) {
+				if pr.Sample.Succeeded() {
+					latency = append(latency, pr.Sample.Metric.Latency)
+				}
+				retries += pr.Sample.Retries()
+				t.events <- latencyProgressMsg{
+					id:      modelRowID,
+					done:    pr.Done,
+					total:   pr.Total,
+					retries: retries,
+					samples: slices.Clone(latency),
+				}
+			}).
+			WithSampleSize(runSampleSize(run, len(prompts)))
+		res, err := eval.Evaluate(ctx)
+		if ctx.Err() != nil {
+			return latencyCancelledMsg{modelRowID, m.Name}
+		}
+		if errors.Is(err, evaluator.ErrNoVision) {
+			return latencySkippedMsg{modelRowID, m.Name, err.Error()}
+		}
 		if err != nil {
-			return latencyErrMsg{modelRowID, m.Name, err.Error()}
+			return newLatencyErrMsg(modelRowID, m.Name, err)
+		}
+
+		var failures, sampleErrs []string
+		var cost float64
+		var outputTokens int
+		priced := true
+		for _, sample := range res.Samples {
+			if !sample.Succeeded() {
+				sampleErrs = append(sampleErrs, sample.Err.Error())
+				continue
+			}
+			if !sample.Passed() {
+				failures = append(failures, sample.Check.Error())
+			}
+
+			c, ok := t.prices.Cost(m, sample.Prompt, sample.Metric.Response)
+			cost += c
+			priced = priced && ok
+			outputTokens += sample.Metric.Response.OutputTokens
 		}
 
 		// Return an updateRowMsg to update the table row
 		return latencyUpdatedMsg{
-			id:      modelRowID,
-			name:    res.ModelName,
-			latency: fmt.Sprintf("%d", res.LatencyAvg.Milliseconds()),
-			samples: res.Latency,
+			id:          modelRowID,
+			name:        res.ModelName,
+			latency:     fmt.Sprintf("%d", res.LatencyAvg.Milliseconds()),
+			samples:     res.Latency,
+			success:     res.SuccessRate(),
+			errRate:     res.ErrorRate(),
+			retries:     res.Retries(),
+			failures:    failures,
+			errs:        sampleErrs,
+			details:     newSampleDetails(res.Samples),
+			cache:       res.Cache(),
+			structured:  res.Structured(),
+			vision:      res.Vision(),
+			reasoning:   res.Reasoning(),
+			toolLatency: res.ToolLatency(),
+			textLatency: res.TextLatency(),
+			firstToken:  res.FirstToken(),
+			cost:        cost,
+			priced:      priced,
+			// Average output tokens, used to estimate cost of further runs.
+			outputTokens: outputTokens / len(res.Latency),
 		}
 	}
 }
 
-// Create a batch of commands to fetch all latency, one model at a time.
-func fetchAllModelLatencyCmd(t *TableComponent) tea.Cmd {
-	counter := 0
-	cmds := make([]tea.Cmd, t.countAllModels())
-	for _, p := range t.providers {
-		for range p.models {
-			cmds[counter] = fetchModelLatencyCmd(t, counter)
-			counter++
-		}
-	}
+const (
+	// Bounds of name column width, name takes space left by other columns.
+	minNameWidth = 16
+	maxNameWidth = 40
 
-	return tea.Batch(cmds...)
-}
+	// minTableHeight is a number of table lines including header, below
+	// which table is not shrunk.
+	minTableHeight = 6
+)
 
-type sortRowsMsg struct{}
+// columnHideOrder lists columns hidden one by one, least useful first,
+// while the table doesn't fit into terminal width.
+var columnHideOrder = []int{columnTrend, columnTool, columnFamily, columnErrors, columnVendor, columnP95, columnTokens, columnID}
+
+// SetWidth fits columns into a given outer width of the table, border
+// included. Name column stretches, other columns are hidden when even the
+// narrowest name column doesn't fit.
+func (s *TableComponent) SetWidth(width int) {
+	s.width = width
+	s.widths = fitColumns(width - 2)
+	s.updateHeader()
+}
 
-func sortRowsCmd(s *TableComponent) tea.Cmd {
-	return func() tea.Msg {
-		sort.SliceStable(s.rows, func(i, j int) bool {
-			latencyI, errI := strconv.Atoi(s.rows[i][4])
-			latencyJ, errJ := strconv.Atoi(s.rows[j][4])
+// SetHeight fits the table into a given outer height, border, filter
+// and help included.
+func (s *TableComponent) SetHeight(height int) {
+	chrome := 2 + lg.Height(s.makeHelpView())
+	if filter := s.makeFilterView(); filter != "" {
+		chrome += lg.Height(filter)
+	}
 
-			if s.sortAsc {
-				if errI != nil {
-					latencyI = math.MaxInt
-				}
-				if errJ != nil {
-					latencyJ = math.MaxInt
-				}
+	s.table.SetHeight(max(minTableHeight, height-chrome))
+}
 
-				return latencyI < latencyJ
-			}
+// fitColumns returns column widths fitted into available width. Each
+// visible column takes two more characters of padding.
+func fitColumns(available int) []int {
+	widths := make([]int, len(tableColumns))
+	for i, c := range tableColumns {
+		widths[i] = c.Width
+	}
 
-			if errI != nil {
-				latencyI = 0
-			}
-			if errJ != nil {
-				latencyJ = 0
+	used := func() int {
+		total := 0
+		for i, w := range widths {
+			if w > 0 && i != columnName {
+				total += w + 2
 			}
+		}
+		return total
+	}
 
-			return latencyI > latencyJ
-		})
-
-		// Toggle sorting order.
-		s.sortAsc = !s.sortAsc
+	for _, column := range columnHideOrder {
+		if used()+minNameWidth+2 <= available {
+			break
+		}
+		widths[column] = 0
+	}
 
-		s.table.SetRows(s.rows)
+	widths[columnName] = min(maxNameWidth, max(minNameWidth, available-used()-2))
 
-		return sortRowsMsg{}
-	}
+	return widths
 }
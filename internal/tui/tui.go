@@ -3,12 +3,18 @@ package tui
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	lg "github.com/charmbracelet/lipgloss"
+	"github.com/pvlbzn/latai/internal/config"
+	"github.com/pvlbzn/latai/internal/evaluator"
+	"github.com/pvlbzn/latai/internal/pricing"
 	"github.com/pvlbzn/latai/internal/provider"
+	"github.com/pvlbzn/latai/internal/scheduler"
+	"github.com/pvlbzn/latai/internal/stats"
 )
 
 var (
@@ -18,9 +24,24 @@ var (
 // TUIModel is a root of Latai TUI application. It holds data and state
 // for the whole application.
 type TUIModel struct {
-	tableComponent  *TableComponent
-	infoComponent   *InfoComponent
-	loggerComponent *LoggerComponent
+	tableComponent     *TableComponent
+	infoComponent      *InfoComponent
+	loggerComponent    *LoggerComponent
+	viewerComponent    *ViewerComponent
+	confirmComponent   *ConfirmComponent
+	compareComponent   *CompareComponent
+	logViewerComponent *LogViewerComponent
+	settingsComponent  *SettingsComponent
+
+	// Embedding models are measured in a separate tab, shown instead of
+	// LLM table while embeddingsTab is true.
+	embeddingsComponent *EmbeddingsComponent
+	embeddingsTab       bool
+
+	// User configuration, persisted on changes such as favorites. It is
+	// never saved if it failed to load to not overwrite user's file.
+	cfg    *config.Config
+	cfgErr error
 
 	width  int
 	height int
@@ -30,6 +51,12 @@ func NewTUIModel() (*TUIModel, error) {
 	var providers []provider.Provider
 	l := NewLoggerComponent(70)
 
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		l.Warn(fmt.Sprintf("Failed to load %s, using defaults: %s", config.Path(), cfgErr))
+		cfg = &config.Config{}
+	}
+
 	// Initialize providers.
 	openai, err := initializeProvider(
 		l,
@@ -61,13 +88,32 @@ func NewTUIModel() (*TUIModel, error) {
 		providers = append(providers, groq)
 	}
 
-	t := NewTableComponent(providers, l)
+	sched := scheduler.New(cfg.SchedulerConfig())
+	prices := pricing.Default().Merge(cfg.Pricing)
+	t := NewTableComponent(providers, prices, sched, l)
+	t.MarkModels(cfg.Favorites[config.DefaultFavorites])
+	t.SetRun(cfg.RunConfig())
 	i := NewInfoComponent(70)
+	v := NewViewerComponent(78, 36)
+	c := NewConfirmComponent(70)
+	cp := NewCompareComponent(78)
+	lv := NewLogViewerComponent(l, 78, 36)
+	st := NewSettingsComponent(78, cfg.RunConfig(), sched.Concurrency())
+	em := NewEmbeddingsComponent(providers, prices, sched, t.events, l)
+	em.SetRun(cfg.RunConfig())
 
 	return &TUIModel{
-		tableComponent:  t,
-		infoComponent:   i,
-		loggerComponent: l,
+		tableComponent:      t,
+		infoComponent:       i,
+		loggerComponent:     l,
+		viewerComponent:     v,
+		confirmComponent:    c,
+		compareComponent:    cp,
+		logViewerComponent:  lv,
+		settingsComponent:   st,
+		embeddingsComponent: em,
+		cfg:                 cfg,
+		cfgErr:              cfgErr,
 	}, nil
 }
 
@@ -79,10 +125,10 @@ func initializeProvider(l *LoggerComponent, name provider.ModelProvider, newProv
 		if name == provider.ModelProviderBedrock {
 			// Bedrock uses a different initialization logic, make message informative
 			// for the use case.
-			l.Push(fmt.Sprintf(
+			l.Warn(fmt.Sprintf(
 				"Bedrock not loaded, verify your `AWS_PROFILE` and `AWS_REGION`."))
 		} else {
-			l.Push(fmt.Sprintf(
+			l.Warn(fmt.Sprintf(
 				"%s not loaded. API key not found, `%s_API_KEY` envar is required.",
 				name, strings.ToUpper(string(name))))
 		}
@@ -91,7 +137,7 @@ func initializeProvider(l *LoggerComponent, name provider.ModelProvider, newProv
 	}
 
 	if ok := p.VerifyAccess(); !ok {
-		l.Push(fmt.Sprintf(
+		l.Warn(fmt.Sprintf(
 			"%s provider is not loaded. API key is invalid, verify your `%s_API_KEY`.",
 			p.Name(), strings.ToUpper(string(p.Name()))))
 		return nil, errProvider
@@ -102,7 +148,7 @@ func initializeProvider(l *LoggerComponent, name provider.ModelProvider, newProv
 }
 
 func (m *TUIModel) Init() tea.Cmd {
-	return nil
+	return m.tableComponent.ListenEvents()
 }
 
 // Update returns a new model and a command. Commands are functions
@@ -110,10 +156,85 @@ func (m *TUIModel) Init() tea.Cmd {
 func (m *TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	// Show events logged while handling a message in opened log viewer.
+	defer m.logViewerComponent.Refresh()
+
+	// Panels below the table may change while handling a message.
+	defer m.fitTable()
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.viewerComponent.Visible() {
+			return m, m.updateViewer(msg)
+		}
+
+		if m.compareComponent.Visible() {
+			return m, m.updateCompare(msg)
+		}
+
+		if m.logViewerComponent.Visible() {
+			return m, m.updateLogViewer(msg)
+		}
+
+		if m.settingsComponent.Visible() {
+			return m, m.updateSettings(msg)
+		}
+
+		if m.confirmComponent.Visible() {
+			_, cmd := m.confirmComponent.Update(msg)
+			return m, cmd
+		}
+
+		if m.embeddingsTab {
+			return m, m.updateEmbeddings(msg)
+		}
+
+		if m.tableComponent.Filtering() {
+			cmd := m.tableComponent.UpdateFilter(msg)
+			return m, tea.Batch(cmd, m.notifySelection())
+		}
+
 		switch msg.String() {
+		case "/":
+			// Filter models.
+			return m, m.tableComponent.StartFilter()
+
+		case "v":
+			// View completions of a selected model.
+			id, model, err := m.tableComponent.GetSelectedRow()
+			if err != nil {
+				m.loggerComponent.Error("Error while selecting row: " + err.Error())
+				return m, nil
+			}
+			m.viewerComponent.Open(id, model.Name)
+			return m, nil
+
+		case "e":
+			// Open all events with full messages.
+			m.logViewerComponent.Open()
+			return m, nil
+
+		case "c":
+			// Compare marked models side by side.
+			m.openCompare()
+			return m, nil
+
+		case "o":
+			// Adjust settings of measurements.
+			m.settingsComponent.Open()
+			return m, nil
+
+		case "tab":
+			// Switch to embedding models.
+			m.embeddingsTab = true
+			return m, nil
+
 		case "esc":
+			// Clear applied filter first, toggle focus otherwise.
+			if m.tableComponent.HasFilter() {
+				m.tableComponent.ClearFilter()
+				return m, m.notifySelection()
+			}
 			m.tableComponent.ToggleFocus()
 			return m, nil
 
@@ -122,9 +243,14 @@ func (m *TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 
 		case "s":
-			// Sort by latency.
-			m.loggerComponent.Push(fmt.Sprintf("Sorting, order asc: %v", m.tableComponent.sortAsc))
-			return m, m.tableComponent.SortByLatency()
+			// Toggle sorting order of a current sort column.
+			m.tableComponent.ToggleSortOrder()
+			return m, m.notifySelection()
+
+		case "S":
+			// Sort by the next sortable column.
+			m.tableComponent.CycleSortColumn()
+			return m, m.notifySelection()
 
 		case "J":
 			// Scroll all the way up.
@@ -153,21 +279,152 @@ func (m *TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, m.tableComponent.MeasureRowLatency()
 
 		case "A":
-			// Run latency measurement for all models.
-			return m, m.tableComponent.MeasureAllRowLatency()
+			// Run latency measurement for all models matching filter after
+			// confirming its cost.
+			m.confirmMeasure(m.tableComponent.VisibleRowIDs())
+			return m, nil
+
+		case " ":
+			// Mark or unmark a selected model and persist favorites.
+			if err := m.tableComponent.ToggleMark(); err != nil {
+				m.loggerComponent.Error("Error while marking row: " + err.Error())
+				return m, nil
+			}
+			m.saveFavorites()
+			return m, nil
+
+		case "x":
+			// Cancel measurement of a selected model.
+			cancelled, err := m.tableComponent.CancelSelectedRow()
+			if err != nil {
+				m.loggerComponent.Error("Error while cancelling row: " + err.Error())
+			} else if !cancelled {
+				m.loggerComponent.Push("Selected model is not being measured")
+			}
+			return m, nil
+
+		case "X":
+			// Cancel all in-flight measurements.
+			m.loggerComponent.Push(fmt.Sprintf("Cancelling %d measurements", m.tableComponent.CancelAll()))
+			return m, nil
+
+		case "m":
+			// Run latency measurement for marked models after confirming its cost.
+			ids := m.tableComponent.MarkedRowIDs()
+			if len(ids) == 0 {
+				m.loggerComponent.Push("No models marked, press space to mark a model")
+				return m, nil
+			}
+			m.confirmMeasure(ids)
+			return m, nil
 		}
 
 	case latencyUpdatedMsg:
-		m.loggerComponent.Push(fmt.Sprintf("%s latency %s ms", msg.name, msg.latency))
-		m.tableComponent.UpdateLatency(msg.id, msg.latency)
-		m.infoComponent.AddInfo(msg.id, msg.latency, msg.samples)
+		m.loggerComponent.Push(fmt.Sprintf("%s latency %s ms, cost %s", msg.name, msg.latency, formatCost(msg.cost, msg.priced)))
+		if len(msg.errs) > 0 {
+			// Panel shows the first error, log viewer shows all of them.
+			m.loggerComponent.Error(fmt.Sprintf(
+				"%s failed %d of %d samples: %s", msg.name, len(msg.errs), len(msg.details), strings.Join(msg.errs, "\n")))
+		}
+		if len(msg.failures) > 0 {
+			// Panel shows the first failure, log viewer shows all of them.
+			m.loggerComponent.Warn(fmt.Sprintf(
+				"%s failed %d of %d checks: %s", msg.name, len(msg.failures), len(msg.samples), strings.Join(msg.failures, "\n")))
+		}
+		if msg.retries > 0 {
+			m.loggerComponent.Warn(fmt.Sprintf("%s retried %d failed calls, they are not included in latency", msg.name, msg.retries))
+		}
+		m.tableComponent.UpdateLatency(msg)
+		m.infoComponent.AddInfo(msg.id, msg.latency, msg.samples, msg.retries, formatCost(msg.cost, msg.priced))
+		if msg.cache != nil {
+			m.loggerComponent.Push(fmt.Sprintf("%s prompt cache: %s", msg.name, formatCache(msg.cache)))
+			m.infoComponent.SetCache(msg.id, formatCache(msg.cache))
+		}
+		if msg.structured != nil {
+			m.loggerComponent.Push(fmt.Sprintf("%s structured output: %s", msg.name, formatStructured(msg.structured)))
+			m.infoComponent.SetStructured(msg.id, formatStructured(msg.structured))
+		}
+		if msg.vision != nil {
+			m.loggerComponent.Push(fmt.Sprintf("%s vision: %s", msg.name, formatVision(msg.vision)))
+			m.infoComponent.SetVision(msg.id, formatVision(msg.vision))
+		}
+		if msg.reasoning != nil {
+			m.loggerComponent.Push(fmt.Sprintf("%s reasoning: %s", msg.name, formatReasoning(msg.reasoning)))
+			m.infoComponent.SetReasoning(msg.id, formatReasoning(msg.reasoning))
+		}
+		if len(msg.toolLatency) > 0 {
+			m.loggerComponent.Push(fmt.Sprintf("%s tool calls: %s", msg.name, formatToolLatency(msg.toolLatency, msg.textLatency)))
+			m.infoComponent.SetTools(msg.id, formatToolLatency(msg.toolLatency, msg.textLatency))
+		}
+		m.viewerComponent.SetSamples(msg.id, msg.details)
+		m.tableComponent.AddCost(msg.id, msg.cost, msg.outputTokens)
+		m.compareComponent.SetResult(msg.id, newCompareResult(msg))
 		return m, nil
 
 	case latencyErrMsg:
-		m.loggerComponent.Push(fmt.Sprintf("Error measuring %s model: %s", msg.name, msg.err))
-		m.tableComponent.SetLatencyError(msg.id)
+		m.loggerComponent.Error(fmt.Sprintf("Error measuring %s model: %s", msg.name, msg.err))
+		m.tableComponent.SetLatencyError(msg.id, msg.label)
+		m.viewerComponent.SetSamples(msg.id, []sampleDetail{{err: msg.err}})
+		return m, nil
+
+	case latencyCancelledMsg:
+		m.loggerComponent.Push(fmt.Sprintf("Measuring %s model cancelled", msg.name))
+		m.tableComponent.SetCancelled(msg.id)
 		return m, nil
 
+	case latencySkippedMsg:
+		m.loggerComponent.Warn(fmt.Sprintf("Skipped %s model: %s", msg.name, msg.reason))
+		m.tableComponent.SetSkipped(msg.id)
+		return m, nil
+
+	case embeddingUpdatedMsg:
+		m.loggerComponent.Push(fmt.Sprintf("%s embeddings: %s", msg.res.ModelName, formatEmbedding(msg.res)))
+		m.embeddingsComponent.UpdateLatency(msg)
+		return m, nil
+
+	case embeddingErrMsg:
+		m.loggerComponent.Error(fmt.Sprintf("Error measuring %s embedding model: %s", msg.name, msg.err))
+		m.embeddingsComponent.SetLatencyError(msg.id, msg.label)
+		return m, nil
+
+	case embeddingCancelledMsg:
+		m.loggerComponent.Push(fmt.Sprintf("Measuring %s embedding model cancelled", msg.name))
+		m.embeddingsComponent.SetCancelled(msg.id)
+		return m, nil
+
+	case embeddingStateMsg:
+		m.embeddingsComponent.SetRowState(msg.id, msg.state)
+		return m, m.tableComponent.ListenEvents()
+
+	case rowStateMsg:
+		m.tableComponent.SetRowState(msg.id, msg.state)
+		return m, m.tableComponent.ListenEvents()
+
+	case latencyProgressMsg:
+		// Progress may arrive after the final result, which wins.
+		if !m.tableComponent.Measuring(msg.id) {
+			return m, m.tableComponent.ListenEvents()
+		}
+		m.tableComponent.SetRowState(msg.id, fmt.Sprintf(
+			"%d/%d ~%d", msg.done, msg.total, stats.Median(msg.samples).Milliseconds()))
+		m.tableComponent.SetTrend(msg.id, msg.samples)
+		m.infoComponent.AddProgress(msg.id, msg.samples, msg.done, msg.total, msg.retries)
+		return m, m.tableComponent.ListenEvents()
+
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.resize()
+		return m, nil
+
+	case tea.MouseMsg:
+		// Sort by a clicked column header, tabs are drawn above the table.
+		if msg.Action == tea.MouseActionRelease && msg.Button == tea.MouseButtonLeft && !m.modalVisible() && !m.embeddingsTab {
+			if column, ok := m.tableComponent.HeaderColumnAt(msg.X, msg.Y-lg.Height(m.makeTabsView())); ok {
+				m.tableComponent.SortByColumn(column)
+				return m, m.notifySelection()
+			}
+		}
+
 	case modelSelectedMsg:
 		m.infoComponent.Update(msg)
 		return m, nil
@@ -181,6 +438,270 @@ func (m *TUIModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// confirmMeasure asks to confirm a run of models with given row IDs
+// showing its estimated cost.
+func (m *TUIModel) confirmMeasure(ids []int) {
+	if len(ids) == 0 {
+		m.loggerComponent.Push("No models match filter")
+		return
+	}
+
+	cost, calls, unpriced, err := m.tableComponent.EstimateRowCost(ids)
+	if err != nil {
+		m.loggerComponent.Error("Error estimating cost: " + err.Error())
+		return
+	}
+
+	message := fmt.Sprintf("Run %d models with %d calls? Estimated cost ~%s.", len(ids), calls, pricing.Format(cost))
+	if unpriced > 0 {
+		message += fmt.Sprintf(" %d models have no price and are not included.", unpriced)
+	}
+
+	m.confirmComponent.Ask(message, func() tea.Cmd {
+		return m.tableComponent.MeasureRowsLatency(ids)
+	})
+}
+
+// confirmMeasureEmbeddings asks to confirm a run of embedding models with
+// given row IDs showing its estimated cost.
+func (m *TUIModel) confirmMeasureEmbeddings(ids []int) {
+	if len(ids) == 0 {
+		m.loggerComponent.Push("All embedding models are being measured")
+		return
+	}
+
+	cost, calls, unpriced := m.embeddingsComponent.EstimateRowCost(ids)
+	message := fmt.Sprintf("Run %d embedding models with %d calls? Estimated cost ~%s.", len(ids), calls, pricing.Format(cost))
+	if unpriced > 0 {
+		message += fmt.Sprintf(" %d models have no price and are not included.", unpriced)
+	}
+
+	m.confirmComponent.Ask(message, func() tea.Cmd {
+		return m.embeddingsComponent.MeasureRows(ids)
+	})
+}
+
+// saveFavorites persists marked models as default favorites. Favorites
+// of models which are not loaded in this session, e.g. of a provider
+// without a key, are kept.
+func (m *TUIModel) saveFavorites() {
+	if m.cfgErr != nil {
+		m.loggerComponent.Warn(fmt.Sprintf("Favorites are not saved, fix %s first: %s", config.Path(), m.cfgErr))
+		return
+	}
+
+	refs := m.tableComponent.MarkedModels()
+	for _, ref := range m.cfg.Favorites[config.DefaultFavorites] {
+		if !m.tableComponent.HasModel(ref) {
+			refs = append(refs, ref)
+		}
+	}
+
+	if m.cfg.Favorites == nil {
+		m.cfg.Favorites = make(map[string][]config.ModelRef)
+	}
+	m.cfg.Favorites[config.DefaultFavorites] = refs
+
+	if err := m.cfg.Save(); err != nil {
+		m.loggerComponent.Error(fmt.Sprintf("Failed to save favorites to %s: %s", config.Path(), err))
+	}
+}
+
+// formatCost formats cost of a measurement, models without price
+// have no cost.
+func formatCost(cost float64, priced bool) string {
+	if !priced {
+		return "n/a"
+	}
+	return pricing.Format(cost)
+}
+
+// modalVisible reports whether a modal covers the table.
+func (m *TUIModel) modalVisible() bool {
+	return m.viewerComponent.Visible() || m.compareComponent.Visible() || m.logViewerComponent.Visible() ||
+		m.settingsComponent.Visible()
+}
+
+// openCompare opens comparison of marked models.
+func (m *TUIModel) openCompare() {
+	ids := m.tableComponent.MarkedRowIDs()
+	if len(ids) < 2 {
+		m.loggerComponent.Push("Mark at least two models with space to compare them")
+		return
+	}
+
+	var models []compareModel
+	for _, id := range ids {
+		_, model, err := m.tableComponent.getModelByRowID(id)
+		if err != nil {
+			m.loggerComponent.Error("Error while comparing rows: " + err.Error())
+			return
+		}
+		models = append(models, compareModel{rowID: id, name: model.Name})
+	}
+
+	m.compareComponent.Open(models)
+}
+
+// newCompareResult converts a measurement into a comparison result.
+func newCompareResult(msg latencyUpdatedMsg) compareResult {
+	var outputTokens int
+	for _, d := range msg.details {
+		outputTokens += d.outputTokens
+	}
+
+	return compareResult{
+		samples:      msg.samples,
+		firstToken:   msg.firstToken,
+		outputTokens: outputTokens,
+		errorRate:    msg.errRate,
+		retries:      msg.retries,
+		cost:         msg.cost,
+		priced:       msg.priced,
+	}
+}
+
+// updateCompare handles keys while comparison is open.
+func (m *TUIModel) updateCompare(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "c", "esc":
+		m.compareComponent.Close()
+
+	case "q", "ctrl+c":
+		return tea.Quit
+	}
+
+	return nil
+}
+
+// updateLogViewer handles keys while log viewer is open.
+func (m *TUIModel) updateLogViewer(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "e", "esc":
+		m.logViewerComponent.Close()
+		return nil
+
+	case "f":
+		m.loggerComponent.ToggleErrorsOnly()
+		return nil
+
+	case "w":
+		path, err := m.loggerComponent.Export(filepath.Join(filepath.Dir(config.Path()), "logs"))
+		if err != nil {
+			m.loggerComponent.Error("Failed to write events: " + err.Error())
+			return nil
+		}
+		m.loggerComponent.Push("Events are written to " + path)
+		return nil
+
+	case "q", "ctrl+c":
+		return tea.Quit
+	}
+
+	_, cmd := m.logViewerComponent.Update(msg)
+	return cmd
+}
+
+// updateSettings handles keys while settings are open. Changes apply
+// right away to measurements started afterwards.
+func (m *TUIModel) updateSettings(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "o", "esc":
+		m.settingsComponent.Close()
+		return nil
+
+	case "w":
+		m.saveSettings()
+		return nil
+
+	case "q", "ctrl+c":
+		return tea.Quit
+	}
+
+	_, cmd := m.settingsComponent.Update(msg)
+	m.tableComponent.SetRun(m.settingsComponent.Run())
+	m.embeddingsComponent.SetRun(m.settingsComponent.Run())
+	m.tableComponent.SetConcurrency(m.settingsComponent.Concurrency())
+	return cmd
+}
+
+// saveSettings persists current settings to the config file.
+func (m *TUIModel) saveSettings() {
+	if m.cfgErr != nil {
+		m.loggerComponent.Warn(fmt.Sprintf("Settings are not saved, fix %s first: %s", config.Path(), m.cfgErr))
+		return
+	}
+
+	run := m.settingsComponent.Run()
+	m.cfg.Run = &run
+	if m.cfg.Scheduler == nil {
+		m.cfg.Scheduler = &scheduler.Config{}
+	}
+	m.cfg.Scheduler.Concurrency = m.settingsComponent.Concurrency()
+
+	if err := m.cfg.Save(); err != nil {
+		m.loggerComponent.Error(fmt.Sprintf("Failed to save settings to %s: %s", config.Path(), err))
+		return
+	}
+	m.loggerComponent.Push("Settings are saved to " + config.Path())
+}
+
+// updateEmbeddings handles keys while embeddings tab is shown.
+func (m *TUIModel) updateEmbeddings(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "tab":
+		m.embeddingsTab = false
+
+	case "j", "down":
+		m.embeddingsComponent.MoveCursorDown()
+
+	case "k", "up":
+		m.embeddingsComponent.MoveCursorUp()
+
+	case "enter":
+		return m.embeddingsComponent.MeasureSelected()
+
+	case "A":
+		// Run all models which are not being measured after confirming
+		// cost, the same way as on the LLM tab.
+		m.confirmMeasureEmbeddings(m.embeddingsComponent.IdleRowIDs())
+
+	case "x":
+		if !m.embeddingsComponent.CancelSelected() {
+			m.loggerComponent.Push("Selected model is not being measured")
+		}
+
+	case "X":
+		m.loggerComponent.Push(fmt.Sprintf("Cancelling %d measurements", m.embeddingsComponent.CancelAll()))
+
+	case "e":
+		m.logViewerComponent.Open()
+
+	case "o":
+		m.settingsComponent.Open()
+
+	case "q", "ctrl+c":
+		return tea.Quit
+	}
+
+	return nil
+}
+
+// updateViewer handles keys while completion viewer is open.
+func (m *TUIModel) updateViewer(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "v", "esc":
+		m.viewerComponent.Close()
+		return nil
+
+	case "q", "ctrl+c":
+		return tea.Quit
+	}
+
+	_, cmd := m.viewerComponent.Update(msg)
+	return cmd
+}
+
 type selectedModel struct {
 	id           int
 	providerName provider.ModelProvider
@@ -199,7 +720,8 @@ func (m *TUIModel) notifySelection() tea.Cmd {
 	return tea.Tick(time.Millisecond*10, func(time.Time) tea.Msg {
 		id, model, err := m.tableComponent.GetSelectedRow()
 		if err != nil {
-			m.loggerComponent.Push("Error while selecting row: " + err.Error())
+			m.loggerComponent.Error("Error while selecting row: " + err.Error())
+			return nil
 		}
 
 		return modelSelectedMsg{
@@ -215,23 +737,161 @@ func (m *TUIModel) notifySelection() tea.Cmd {
 }
 
 func (m *TUIModel) View() string {
+	if m.viewerComponent.Visible() {
+		return m.viewerComponent.View()
+	}
+
+	if m.compareComponent.Visible() {
+		return m.compareComponent.View()
+	}
+
+	if m.logViewerComponent.Visible() {
+		return m.logViewerComponent.View()
+	}
+
+	if m.settingsComponent.Visible() {
+		return m.settingsComponent.View()
+	}
+
+	panels := m.makePanelsView()
+	tabs := m.makeTabsView()
+
+	table := m.tableComponent.View()
+	if m.embeddingsTab {
+		table = m.embeddingsComponent.View()
+	}
+
 	return lg.JoinVertical(
 		lg.Top,
-		m.tableComponent.View(),
-		m.infoComponent.View(),
-		m.loggerComponent.View(),
+		tabs,
+		table,
+		panels,
 	)
 }
 
+// makePanelsView returns info and events panels shown below the table.
+func (m *TUIModel) makePanelsView() string {
+	info := m.infoComponent.View()
+	if m.embeddingsTab {
+		info = m.embeddingsComponent.DetailsView()
+	}
+	if m.confirmComponent.Visible() {
+		info = m.confirmComponent.View()
+	}
+
+	if m.sideBySide() {
+		return lg.JoinHorizontal(lg.Top, info, m.loggerComponent.View())
+	}
+	return lg.JoinVertical(lg.Top, info, m.loggerComponent.View())
+}
+
+// makeTabsView returns a line of tabs with the shown one highlighted.
+func (m *TUIModel) makeTabsView() string {
+	active := lg.NewStyle().
+		Bold(true).
+		Foreground(lg.Color("#fff")).
+		Background(lg.Color("#2b5ccc")).
+		Padding(0, 1)
+	inactive := lg.NewStyle().
+		Foreground(lg.Color("241")).
+		Padding(0, 1)
+
+	models, embeddings := active, inactive
+	if m.embeddingsTab {
+		models, embeddings = inactive, active
+	}
+
+	return lg.JoinHorizontal(lg.Top, models.Render("Models"), embeddings.Render("Embeddings"),
+		inactive.Render("tab: switch"))
+}
+
 type latencyUpdatedMsg struct {
+	id       int
+	name     string
+	latency  string
+	samples  []time.Duration
+	success  float64
+	failures []string
+	details  []sampleDetail
+
+	// Share of failed samples and their errors, failed samples are not
+	// included in latency.
+	errRate float64
+	errs    []string
+
+	// Failed attempts retried, not included in latency.
+	retries int
+
+	// Result of prompt caching measurement, nil unless run in cache mode.
+	cache *evaluator.CacheResult
+
+	// Result of structured output measurement, nil unless some prompt
+	// requested it.
+	structured *evaluator.StructuredResult
+
+	// Result of vision measurement, nil unless some prompt attached images.
+	vision *evaluator.VisionResult
+
+	// Split of output tokens into hidden reasoning and visible output, nil
+	// unless model reported reasoning.
+	reasoning *evaluator.ReasoningResult
+
+	// Latency of samples of prompts which declare tools and of the rest,
+	// tool calling turns are reported apart from plain completions.
+	toolLatency []time.Duration
+	textLatency []time.Duration
+
+	// Time to first token of streamed samples, empty if completions
+	// weren't streamed.
+	firstToken []time.Duration
+
+	// Cost of measurement, priced is false if model has no price.
+	cost         float64
+	priced       bool
+	outputTokens int
+}
+
+type latencyErrMsg struct {
+	id   int
+	name string
+
+	// Label of error class, such as `throttled`, and full error message.
+	label string
+	err   string
+}
+
+// latencyProgressMsg reports latency of samples measured so far by
+// a running measurement.
+type latencyProgressMsg struct {
 	id      int
-	name    string
-	latency string
+	done    int
+	total   int
+	retries int
 	samples []time.Duration
 }
 
-type latencyErrMsg struct {
+// latencyCancelledMsg reports a measurement cancelled by user.
+type latencyCancelledMsg struct {
 	id   int
 	name string
-	err  string
+}
+
+// latencySkippedMsg reports a model which can't run prompts, such as
+// a model without vision given images.
+type latencySkippedMsg struct {
+	id     int
+	name   string
+	reason string
+}
+
+// States of a measurement shown in latency cell until it finishes.
+const (
+	rowStateQueued  = "queued"
+	rowStateRunning = "running"
+)
+
+// rowStateMsg reports a change of measurement state of a row.
+type rowStateMsg struct {
+	id    int
+	state string
 }
@@ -0,0 +1,267 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	lg "github.com/charmbracelet/lipgloss"
+	"github.com/pvlbzn/latai/internal/evaluator"
+)
+
+// ViewerComponent is a modal which lists completions of each sample of
+// a measured model, so that fast yet wrong answers can be spotted.
+type ViewerComponent struct {
+	width  int
+	height int
+
+	viewport viewport.Model
+	visible  bool
+
+	// Row and model name of opened samples.
+	rowID     int
+	modelName string
+
+	samples map[int][]sampleDetail
+}
+
+// sampleDetail is a display ready data of a single sample.
+type sampleDetail struct {
+	prompt       string
+	completion   string
+	latency      time.Duration
+	inputTokens  int
+	outputTokens int
+	check        string
+
+	// Output tokens spent on hidden reasoning, a part of output tokens.
+	reasoningTokens int
+	err             string
+
+	// Tool calls returned instead of or along with completion, formatted
+	// as `name(arguments)`.
+	toolCalls []string
+
+	// Errors of failed attempts retried before the last one.
+	retries []string
+}
+
+// newSampleDetails converts evaluator samples into display ready details.
+func newSampleDetails(samples []*evaluator.Sample) []sampleDetail {
+	details := make([]sampleDetail, 0, len(samples))
+	for _, s := range samples {
+		d := sampleDetail{prompt: describePrompt(s)}
+		if s.Succeeded() {
+			d.completion = s.Metric.Response.Completion
+			d.latency = s.Metric.Latency
+			d.inputTokens = s.Metric.Response.InputTokens
+			d.outputTokens = s.Metric.Response.OutputTokens
+			d.reasoningTokens = s.Metric.Response.ReasoningTokens
+			for _, c := range s.Metric.Response.ToolCalls {
+				d.toolCalls = append(d.toolCalls, fmt.Sprintf("%s(%s)", c.Name, c.Arguments))
+			}
+		} else {
+			d.err = s.Err.Error()
+		}
+		if s.Check != nil {
+			d.check = s.Check.Error()
+		}
+
+		// The last attempt is either measured or is the error of the sample.
+		for _, a := range s.Attempts[:max(0, len(s.Attempts)-1)] {
+			d.retries = append(d.retries, a.Err.Error())
+		}
+		details = append(details, d)
+	}
+
+	return details
+}
+
+func describePrompt(s *evaluator.Sample) string {
+	if s.Prompt == nil {
+		return ""
+	}
+
+	desc := s.Prompt.Content
+	if len(s.Prompt.Messages) > 0 {
+		desc = fmt.Sprintf("%s\n(after %d prior turns)", desc, len(s.Prompt.Messages))
+	}
+	if s.Prompt.System != "" {
+		desc = fmt.Sprintf("[system] %s\n%s", s.Prompt.System, desc)
+	}
+	for _, img := range s.Prompt.Images {
+		name := img.Path
+		if name == "" {
+			name = "generated"
+		}
+		desc = fmt.Sprintf("%s\n[image] %s, %s, %d KB", desc, name, img.MediaType, len(img.Data)/1024)
+	}
+	if len(s.Prompt.ResponseSchema) > 0 {
+		desc = fmt.Sprintf("%s\n[response schema] %s", desc, s.Prompt.ResponseSchema)
+	}
+	for _, t := range s.Prompt.Tools {
+		desc = fmt.Sprintf("%s\n[tool] %s", desc, t.Name)
+	}
+
+	return desc
+}
+
+func NewViewerComponent(width, height int) *ViewerComponent {
+	return &ViewerComponent{
+		width:    width,
+		height:   height,
+		viewport: viewport.New(width, height-4),
+		samples:  make(map[int][]sampleDetail),
+	}
+}
+
+func (s *ViewerComponent) Init() tea.Cmd {
+	return nil
+}
+
+// Update scrolls the viewer.
+func (s *ViewerComponent) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	s.viewport, cmd = s.viewport.Update(msg)
+	return s, cmd
+}
+
+// SetSize sets inner size of the modal, viewport takes all of it except
+// header and help lines.
+func (s *ViewerComponent) SetSize(width, height int) {
+	s.width, s.height = width, height
+	s.viewport.Width = width
+	s.viewport.Height = max(1, height-4)
+
+	// Re-wrap opened samples.
+	if s.visible {
+		s.viewport.SetContent(s.renderSamples(s.modelName, s.samples[s.rowID]))
+	}
+}
+
+// Visible reports whether viewer is open.
+func (s *ViewerComponent) Visible() bool {
+	return s.visible
+}
+
+// Open shows samples of a model under a given row ID.
+func (s *ViewerComponent) Open(rowID int, modelName string) {
+	s.visible = true
+	s.rowID, s.modelName = rowID, modelName
+	s.viewport.SetContent(s.renderSamples(modelName, s.samples[rowID]))
+	s.viewport.GotoTop()
+}
+
+func (s *ViewerComponent) Close() {
+	s.visible = false
+}
+
+// SetSamples replaces samples of a row with results of a latest measurement.
+func (s *ViewerComponent) SetSamples(rowID int, samples []sampleDetail) {
+	s.samples[rowID] = samples
+
+	// Refresh opened samples if measurement finished while viewing.
+	if s.visible && s.rowID == rowID {
+		s.viewport.SetContent(s.renderSamples(s.modelName, samples))
+	}
+}
+
+func (s *ViewerComponent) View() string {
+	container := lg.NewStyle().
+		BorderStyle(lg.NormalBorder()).
+		BorderForeground(lg.Color("241")).
+		Width(s.width)
+
+	header := lg.NewStyle().
+		Bold(true).
+		PaddingLeft(1).
+		Render("Completions")
+
+	separator := lg.NewStyle().
+		Foreground(lg.Color("240")).
+		Render(strings.Repeat("─", s.width))
+
+	help := lg.NewStyle().
+		Foreground(lg.Color("241")).
+		PaddingLeft(1).
+		Render(fmt.Sprintf("j/k: scroll | v/esc: close | %3.f%%", s.viewport.ScrollPercent()*100))
+
+	return container.Render(lg.JoinVertical(
+		lg.Top,
+		header,
+		separator,
+		s.viewport.View(),
+		help))
+}
+
+func (s *ViewerComponent) renderSamples(modelName string, samples []sampleDetail) string {
+	muted := lg.NewStyle().Foreground(lg.Color("240"))
+	label := lg.NewStyle().Bold(true)
+	text := lg.NewStyle().Width(s.width - 2).PaddingLeft(1)
+	failure := text.Foreground(lg.Color("203"))
+
+	if len(samples) == 0 {
+		return text.Inherit(muted).Render(fmt.Sprintf("No samples for %s. Press enter to run a measurement.", modelName))
+	}
+
+	var b strings.Builder
+	b.WriteString(text.Inherit(label).Render(modelName))
+	b.WriteString("\n\n")
+
+	for i, d := range samples {
+		title := fmt.Sprintf("#%d  %d ms  tokens in %d / out %d", i+1, d.latency.Milliseconds(), d.inputTokens, d.outputTokens)
+		if d.reasoningTokens > 0 {
+			title += fmt.Sprintf(" (reasoning %d)", d.reasoningTokens)
+		}
+		if d.err != "" {
+			title = fmt.Sprintf("#%d  failed", i+1)
+		}
+		if len(d.retries) > 0 {
+			title += fmt.Sprintf("  retries %d", len(d.retries))
+		}
+		b.WriteString(text.Inherit(label).Render(title))
+		b.WriteString("\n")
+
+		if d.prompt != "" {
+			b.WriteString(text.Inherit(muted).Render("Prompt:"))
+			b.WriteString("\n")
+			b.WriteString(text.Render(d.prompt))
+			b.WriteString("\n")
+		}
+
+		if d.err == "" {
+			b.WriteString(text.Inherit(muted).Render("Completion:"))
+			b.WriteString("\n")
+			b.WriteString(text.Render(d.completion))
+			b.WriteString("\n")
+		}
+
+		if len(d.toolCalls) > 0 {
+			b.WriteString(text.Inherit(muted).Render("Tool calls:"))
+			b.WriteString("\n")
+			b.WriteString(text.Render(strings.Join(d.toolCalls, "\n")))
+			b.WriteString("\n")
+		}
+
+		if d.check != "" {
+			b.WriteString(failure.Render("Check: " + d.check))
+			b.WriteString("\n")
+		}
+
+		for _, r := range d.retries {
+			b.WriteString(text.Inherit(muted).Render("Retried: " + r))
+			b.WriteString("\n")
+		}
+
+		if d.err != "" {
+			b.WriteString(failure.Render("Error: " + d.err))
+			b.WriteString("\n")
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}